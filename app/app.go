@@ -2,43 +2,326 @@ package app
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/tkeel-io/kit/log"
+	"github.com/tkeel-io/kit/registry"
 	"github.com/tkeel-io/kit/transport"
+	"github.com/tkeel-io/kit/transport/handoff"
+	"github.com/tkeel-io/kit/version"
+	"go.uber.org/multierr"
 )
 
+// DefaultStopTimeout is the default deadline for stopping all servers in Run.
+const DefaultStopTimeout = 10 * time.Second
+
+var _ Info = (*App)(nil)
+
 type App struct {
-	Name       string
-	serverList []transport.Server
+	opts     options
+	levels   [][]*component
+	instance *registry.ServiceInstance
 }
 
-func New(name string, conf *log.Conf, srv ...transport.Server) *App {
-	if err := log.InitLoggerByConf(conf); err != nil {
-		panic(err)
+// New creates an application with name, the logger is initialized
+// when WithLogger is given.
+func New(name string, opts ...Option) (*App, error) {
+	o := options{
+		name:        name,
+		version:     version.Version,
+		stopTimeout: DefaultStopTimeout,
+		signals:     []os.Signal{syscall.SIGINT, syscall.SIGTERM},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.id == "" {
+		id, err := newID()
+		if err != nil {
+			return nil, fmt.Errorf("error new app: %w", err)
+		}
+		o.id = id
 	}
-	app := &App{
-		Name:       name,
-		serverList: srv,
+	if o.logConf != nil {
+		if err := log.InitLoggerByConf(o.logConf); err != nil {
+			return nil, fmt.Errorf("error new app: %w", err)
+		}
+	}
+	levels, err := sortComponents(o.components)
+	if err != nil {
+		return nil, fmt.Errorf("error new app: %w", err)
+	}
+	return &App{opts: o, levels: levels}, nil
+}
+
+// ID returns the app instance ID.
+func (a *App) ID() string {
+	return a.opts.id
+}
+
+// Name returns the app name.
+func (a *App) Name() string {
+	return a.opts.name
+}
+
+// Version returns the app version.
+func (a *App) Version() string {
+	return a.opts.version
+}
+
+// Metadata returns the app metadata.
+func (a *App) Metadata() map[string]string {
+	return a.opts.metadata
+}
+
+// newID generates a random UUID.
+func newID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("error generate id: %w", err)
 	}
-	return app
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
 }
 
+// Run starts all servers and blocks until ctx is done or one of the
+// signals is received, then stops all servers within the stop timeout.
+// With WithGracefulRestart, the restart signal hands off the listeners
+// to a new process, which makes this one stop once it is serving.
 func (a *App) Run(ctx context.Context) error {
-	for _, v := range a.serverList {
+	if err := a.Start(ctx); err != nil {
+		return err
+	}
+	if err := handoff.Ready(); err != nil {
+		log.Errorf("app %s error notify old process: %s", a.opts.name, err)
+	}
+
+	var c, restart chan os.Signal
+	if sigs := a.stopSignals(); len(sigs) > 0 {
+		c = make(chan os.Signal, 1)
+		signal.Notify(c, sigs...)
+		defer signal.Stop(c)
+	}
+	if a.opts.restartSignal != nil {
+		restart = make(chan os.Signal, 1)
+		signal.Notify(restart, a.opts.restartSignal)
+		defer signal.Stop(restart)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	serveErrc := a.watchServers(done)
+
+	var (
+		serveErr error
+		// childExit is not nil while a new process is starting.
+		childExit chan error
+	)
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			log.Infof("app %s context done: %s", a.opts.name, ctx.Err())
+			break loop
+		case sig := <-c:
+			log.Infof("app %s receive signal: %s", a.opts.name, sig)
+			break loop
+		case serveErr = <-serveErrc:
+			log.Errorf("app %s shutting down: %s", a.opts.name, serveErr)
+			break loop
+		case sig := <-restart:
+			if childExit != nil {
+				log.Warnf("app %s receive signal: %s, restart in progress", a.opts.name, sig)
+				continue
+			}
+			log.Infof("app %s receive signal: %s, restarting", a.opts.name, sig)
+			p, err := handoff.Restart()
+			if err != nil {
+				log.Errorf("app %s error restart: %s", a.opts.name, err)
+				continue
+			}
+			childExit = make(chan error, 1)
+			go func(p *os.Process, c chan<- error) {
+				state, err := p.Wait()
+				if err == nil {
+					err = fmt.Errorf("process exited: %s", state)
+				}
+				c <- err
+			}(p, childExit)
+		case err := <-childExit:
+			log.Errorf("app %s error restart: %s", a.opts.name, err)
+			childExit = nil
+		}
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), a.opts.stopTimeout)
+	defer cancel()
+	return multierr.Append(serveErr, a.Stop(stopCtx))
+}
+
+// stopSignals returns the signals which stop Run. With graceful restart
+// SIGTERM is always one of them, as the new process sends it to the old one
+// by handoff.Ready.
+func (a *App) stopSignals() []os.Signal {
+	if a.opts.restartSignal == nil {
+		return a.opts.signals
+	}
+	for _, sig := range a.opts.signals {
+		if sig == syscall.SIGTERM {
+			return a.opts.signals
+		}
+	}
+	return append(a.opts.signals[:len(a.opts.signals):len(a.opts.signals)], syscall.SIGTERM)
+}
+
+// watchServers returns a channel receiving the first error reported by a
+// server implementing transport.ErrorNotifier, until done is closed.
+func (a *App) watchServers(done <-chan struct{}) <-chan error {
+	errc := make(chan error, len(a.opts.servers))
+	for _, v := range a.opts.servers {
+		n, ok := v.(transport.ErrorNotifier)
+		if !ok {
+			continue
+		}
+		go func(t transport.Type, c <-chan error) {
+			select {
+			case err := <-c:
+				errc <- fmt.Errorf("error serve server(%s): %w", t, err)
+			case <-done:
+			}
+		}(v.Type(), n.Err())
+	}
+	return errc
+}
+
+// Start runs the before start hooks, starts all components and servers
+// and runs the after start hooks, then returns without waiting.
+// If a server or an after start hook fails, the instance is deregistered and
+// the servers and components already started are stopped in reverse order
+// before the error is returned.
+// The context given to hooks, components and servers carries the app info.
+// The transport.Readier servers are set ready once everything started.
+func (a *App) Start(ctx context.Context) error {
+	ctx = NewContext(ctx, a)
+	if err := runHooks(ctx, "before start", a.opts.beforeStart); err != nil {
+		return err
+	}
+	if err := a.startComponents(ctx); err != nil {
+		return err
+	}
+	for i, v := range a.opts.servers {
 		if err := v.Start(ctx); err != nil {
-			return fmt.Errorf("error start server(%s): %w", v.Type(), err)
+			err = fmt.Errorf("error start server(%s): %w", v.Type(), err)
+			return multierr.Append(err, a.rollback(a.opts.servers[:i]))
 		}
 	}
-	log.Infof("app %s running", a.Name)
+	if err := a.register(ctx); err != nil {
+		return multierr.Append(err, a.rollback(a.opts.servers))
+	}
+	if err := runHooks(ctx, "after start", a.opts.afterStart); err != nil {
+		err = multierr.Append(err, a.deregister(ctx))
+		return multierr.Append(err, a.rollback(a.opts.servers))
+	}
+	a.setReady(true)
+	log.Infof("app %s running", a.opts.name)
 	return nil
 }
 
-func (a *App) Stop(ctx context.Context) error {
-	for _, v := range a.serverList {
-		if err := v.Stop(ctx); err != nil {
-			return fmt.Errorf("error stop server(%s): %w", v.Type(), err)
+// setReady reports the readiness to the servers implementing transport.Readier.
+func (a *App) setReady(ready bool) {
+	for _, v := range a.opts.servers {
+		if r, ok := v.(transport.Readier); ok {
+			r.SetReady(ready)
 		}
 	}
+}
+
+// register registers the endpoints of the servers to the registrar.
+func (a *App) register(ctx context.Context) error {
+	if a.opts.registrar == nil {
+		return nil
+	}
+	instance, err := a.buildInstance()
+	if err != nil {
+		return err
+	}
+	if err := a.opts.registrar.Register(ctx, instance); err != nil {
+		return fmt.Errorf("error register instance: %w", err)
+	}
+	a.instance = instance
+	return nil
+}
+
+// deregister deregisters the instance registered by register.
+func (a *App) deregister(ctx context.Context) error {
+	if a.instance == nil {
+		return nil
+	}
+	instance := a.instance
+	a.instance = nil
+	if err := a.opts.registrar.Deregister(ctx, instance); err != nil {
+		return fmt.Errorf("error deregister instance: %w", err)
+	}
 	return nil
 }
+
+func (a *App) buildInstance() (*registry.ServiceInstance, error) {
+	endpoints := make([]string, 0, len(a.opts.servers))
+	for _, v := range a.opts.servers {
+		e, ok := v.(transport.Endpointer)
+		if !ok {
+			continue
+		}
+		u, err := e.Endpoint()
+		if err != nil {
+			return nil, fmt.Errorf("error get server(%s) endpoint: %w", v.Type(), err)
+		}
+		endpoints = append(endpoints, u.String())
+	}
+	return &registry.ServiceInstance{
+		ID:        a.opts.id,
+		Name:      a.opts.name,
+		Version:   a.opts.version,
+		Metadata:  a.opts.metadata,
+		Endpoints: endpoints,
+	}, nil
+}
+
+func (a *App) rollback(started []transport.Server) error {
+	stopCtx, cancel := context.WithTimeout(context.Background(), a.opts.stopTimeout)
+	defer cancel()
+	var err error
+	for i := len(started) - 1; i >= 0; i-- {
+		v := started[i]
+		if e := v.Stop(stopCtx); e != nil {
+			err = multierr.Append(err, fmt.Errorf("error rollback server(%s): %w", v.Type(), e))
+		}
+	}
+	return multierr.Append(err, a.stopComponents(stopCtx))
+}
+
+// Stop sets the transport.Readier servers unready, runs the before stop
+// hooks, stops every server and component and runs the after stop hooks,
+// the returned error combines all of their errors.
+func (a *App) Stop(ctx context.Context) error {
+	ctx = NewContext(ctx, a)
+	a.setReady(false)
+	err := runAllHooks(ctx, "before stop", a.opts.beforeStop)
+	err = multierr.Append(err, a.deregister(ctx))
+	for _, v := range a.opts.servers {
+		if e := v.Stop(ctx); e != nil {
+			err = multierr.Append(err, fmt.Errorf("error stop server(%s): %w", v.Type(), e))
+		}
+	}
+	err = multierr.Append(err, a.stopComponents(ctx))
+	err = multierr.Append(err, runAllHooks(ctx, "after stop", a.opts.afterStop))
+	if err == nil {
+		log.Infof("app %s stopped", a.opts.name)
+	}
+	return err
+}
@@ -0,0 +1,323 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tkeel-io/kit/log"
+	"github.com/tkeel-io/kit/registry"
+	"github.com/tkeel-io/kit/transport"
+	"github.com/tkeel-io/kit/version"
+)
+
+type testServer struct {
+	typ      transport.Type
+	started  bool
+	stopped  bool
+	startErr error
+	stopErr  error
+	events   *[]string
+}
+
+func (s *testServer) Type() transport.Type {
+	return s.typ
+}
+
+func (s *testServer) Start(ctx context.Context) error {
+	if s.startErr != nil {
+		return s.startErr
+	}
+	s.started = true
+	s.record("start")
+	return nil
+}
+
+func (s *testServer) Stop(ctx context.Context) error {
+	s.stopped = true
+	s.record("stop")
+	return s.stopErr
+}
+
+func (s *testServer) record(event string) {
+	if s.events != nil {
+		*s.events = append(*s.events, event+" "+string(s.typ))
+	}
+}
+
+func newTestApp(srv ...transport.Server) *App {
+	a, err := New("test",
+		WithServer(srv...),
+		WithSignal(syscall.SIGUSR1),
+		WithStopTimeout(time.Second),
+	)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func TestNew(t *testing.T) {
+	a, err := New("test", WithMetadata(map[string]string{"k": "v"}))
+	require.NoError(t, err)
+	assert.Equal(t, "test", a.Name())
+	assert.Equal(t, map[string]string{"k": "v"}, a.Metadata())
+	assert.Equal(t, DefaultStopTimeout, a.opts.stopTimeout)
+
+	_, err = New("test", WithLogger(&log.Conf{App: "test", Output: []string{"/not/exist/dir/log"}}))
+	assert.Error(t, err)
+}
+
+func TestRunContextDone(t *testing.T) {
+	s1 := &testServer{typ: transport.TypeHTTP}
+	s2 := &testServer{typ: transport.TypeGRPC}
+	a := newTestApp(s1, s2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.NoError(t, a.Run(ctx))
+	assert.True(t, s1.started && s1.stopped)
+	assert.True(t, s2.started && s2.stopped)
+}
+
+func TestRunSignal(t *testing.T) {
+	s := &testServer{typ: transport.TypeHTTP}
+	a := newTestApp(s)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	}()
+	require.NoError(t, a.Run(context.Background()))
+	assert.True(t, s.stopped)
+}
+
+func TestStopCombineErrors(t *testing.T) {
+	errHTTP := errors.New("http")
+	errGRPC := errors.New("grpc")
+	s1 := &testServer{typ: transport.TypeHTTP, stopErr: errHTTP}
+	s2 := &testServer{typ: transport.TypeGRPC, stopErr: errGRPC}
+	a := newTestApp(s1, s2)
+
+	err := a.Stop(context.Background())
+	assert.ErrorIs(t, err, errHTTP)
+	assert.ErrorIs(t, err, errGRPC)
+	assert.True(t, s2.stopped)
+}
+
+func TestStartRollback(t *testing.T) {
+	var events []string
+	errStart := errors.New("start")
+	errRollback := errors.New("rollback")
+	s1 := &testServer{typ: "s1", events: &events}
+	s2 := &testServer{typ: "s2", events: &events, stopErr: errRollback}
+	s3 := &testServer{typ: "s3", events: &events, startErr: errStart}
+	s4 := &testServer{typ: "s4", events: &events}
+	a := newTestApp(s1, s2, s3, s4)
+
+	err := a.Run(context.Background())
+	assert.ErrorIs(t, err, errStart)
+	assert.ErrorIs(t, err, errRollback)
+	assert.Equal(t, []string{"start s1", "start s2", "stop s2", "stop s1"}, events)
+	assert.False(t, s3.stopped)
+	assert.False(t, s4.started)
+}
+
+type notifyServer struct {
+	testServer
+	errCh chan error
+}
+
+func (s *notifyServer) Err() <-chan error {
+	return s.errCh
+}
+
+func TestRunServeError(t *testing.T) {
+	errServe := errors.New("serve")
+	s1 := &testServer{typ: transport.TypeHTTP}
+	s2 := &notifyServer{testServer: testServer{typ: transport.TypeGRPC}, errCh: make(chan error, 1)}
+	a := newTestApp(s1, s2)
+
+	s2.errCh <- errServe
+	err := a.Run(context.Background())
+	assert.ErrorIs(t, err, errServe)
+	assert.True(t, s1.stopped)
+	assert.True(t, s2.stopped)
+}
+
+func TestHooks(t *testing.T) {
+	var events []string
+	hook := func(name string, err error) Hook {
+		return func(ctx context.Context) error {
+			events = append(events, name)
+			return err
+		}
+	}
+	errAfterStop := errors.New("after stop")
+	s := &testServer{typ: transport.TypeHTTP, events: &events}
+	a, err := New("test",
+		WithServer(s),
+		WithBeforeStart(hook("before start 1", nil), hook("before start 2", nil)),
+		WithAfterStart(hook("after start", nil)),
+		WithBeforeStop(hook("before stop", nil)),
+		WithAfterStop(hook("after stop 1", errAfterStop), hook("after stop 2", nil)),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, a.Start(context.Background()))
+	assert.ErrorIs(t, a.Stop(context.Background()), errAfterStop)
+	assert.Equal(t, []string{
+		"before start 1", "before start 2", "start HTTP", "after start",
+		"before stop", "stop HTTP", "after stop 1", "after stop 2",
+	}, events)
+}
+
+func TestHooksAbortStart(t *testing.T) {
+	errBeforeStart := errors.New("before start")
+	s := &testServer{typ: transport.TypeHTTP}
+	a, err := New("test",
+		WithServer(s),
+		WithBeforeStart(func(ctx context.Context) error { return errBeforeStart }),
+	)
+	require.NoError(t, err)
+	assert.ErrorIs(t, a.Start(context.Background()), errBeforeStart)
+	assert.False(t, s.started)
+
+	errAfterStart := errors.New("after start")
+	s = &testServer{typ: transport.TypeHTTP}
+	a, err = New("test",
+		WithServer(s),
+		WithAfterStart(func(ctx context.Context) error { return errAfterStart }),
+	)
+	require.NoError(t, err)
+	assert.ErrorIs(t, a.Start(context.Background()), errAfterStart)
+	assert.True(t, s.stopped)
+}
+
+func TestInfo(t *testing.T) {
+	var info Info
+	a, err := New("test",
+		WithMetadata(map[string]string{"k": "v"}),
+		WithBeforeStart(func(ctx context.Context) error {
+			info, _ = FromContext(ctx)
+			return nil
+		}),
+	)
+	require.NoError(t, err)
+	assert.Len(t, a.ID(), 36)
+	assert.Equal(t, version.Version, a.Version())
+
+	require.NoError(t, a.Start(context.Background()))
+	require.NotNil(t, info)
+	assert.Equal(t, a.ID(), info.ID())
+	assert.Equal(t, "test", info.Name())
+	assert.Equal(t, map[string]string{"k": "v"}, info.Metadata())
+
+	a, err = New("test", WithID("id"), WithVersion("v1"))
+	require.NoError(t, err)
+	assert.Equal(t, "id", a.ID())
+	assert.Equal(t, "v1", a.Version())
+}
+
+type endpointServer struct {
+	testServer
+	endpoint string
+}
+
+func (s *endpointServer) Endpoint() (*url.URL, error) {
+	return url.Parse(s.endpoint)
+}
+
+func TestRegistrar(t *testing.T) {
+	r := registry.NewMemory()
+	var registered []*registry.ServiceInstance
+	a, err := New("test",
+		WithServer(
+			&endpointServer{testServer: testServer{typ: transport.TypeHTTP}, endpoint: "http://127.0.0.1:31234"},
+			&endpointServer{testServer: testServer{typ: transport.TypeGRPC}, endpoint: "grpc://127.0.0.1:31233"},
+			&testServer{typ: "other"},
+		),
+		WithRegistrar(r),
+		WithAfterStart(func(ctx context.Context) (err error) {
+			registered, err = r.GetService(ctx, "test")
+			return err
+		}),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, a.Start(context.Background()))
+	require.Len(t, registered, 1)
+	assert.Equal(t, a.ID(), registered[0].ID)
+	assert.Equal(t, []string{"http://127.0.0.1:31234", "grpc://127.0.0.1:31233"}, registered[0].Endpoints)
+
+	require.NoError(t, a.Stop(context.Background()))
+	instances, err := r.GetService(context.Background(), "test")
+	require.NoError(t, err)
+	assert.Empty(t, instances)
+}
+
+func TestRegistrarAfterStartError(t *testing.T) {
+	r := registry.NewMemory()
+	errAfterStart := errors.New("after start")
+	s := &endpointServer{testServer: testServer{typ: transport.TypeHTTP}, endpoint: "http://127.0.0.1:31234"}
+	a, err := New("test",
+		WithServer(s),
+		WithRegistrar(r),
+		WithAfterStart(func(ctx context.Context) error { return errAfterStart }),
+	)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, a.Start(context.Background()), errAfterStart)
+	assert.True(t, s.stopped)
+	instances, err := r.GetService(context.Background(), "test")
+	require.NoError(t, err)
+	assert.Empty(t, instances)
+}
+
+type readyServer struct {
+	testServer
+	ready bool
+}
+
+func (s *readyServer) SetReady(ready bool) {
+	s.ready = ready
+}
+
+func TestReadier(t *testing.T) {
+	s := &readyServer{testServer: testServer{typ: transport.TypeAdmin}}
+	var readyOnAfterStart bool
+	a, err := New("test",
+		WithServer(s),
+		WithAfterStart(func(ctx context.Context) error {
+			readyOnAfterStart = s.ready
+			return nil
+		}),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, a.Start(context.Background()))
+	assert.False(t, readyOnAfterStart)
+	assert.True(t, s.ready)
+	require.NoError(t, a.Stop(context.Background()))
+	assert.False(t, s.ready)
+}
+
+func TestStopSignals(t *testing.T) {
+	a, err := New("test", WithSignal(syscall.SIGINT))
+	require.NoError(t, err)
+	assert.Equal(t, []os.Signal{syscall.SIGINT}, a.stopSignals())
+
+	a, err = New("test", WithSignal(syscall.SIGINT), WithGracefulRestart(syscall.SIGHUP))
+	require.NoError(t, err)
+	assert.Equal(t, []os.Signal{syscall.SIGINT, syscall.SIGTERM}, a.stopSignals())
+
+	a, err = New("test", WithGracefulRestart(syscall.SIGHUP))
+	require.NoError(t, err)
+	assert.Equal(t, []os.Signal{syscall.SIGINT, syscall.SIGTERM}, a.stopSignals())
+}
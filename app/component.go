@@ -0,0 +1,155 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/multierr"
+)
+
+// Component is a part of the app such as a cache, a DB client or a consumer.
+// Components start before the servers and stop after them.
+type Component interface {
+	Name() string
+	Start(context.Context) error
+	Stop(context.Context) error
+}
+
+type component struct {
+	Component
+	dependsOn []string
+}
+
+// WithComponent registers c, which starts after and stops before the
+// components named in dependsOn.
+func WithComponent(c Component, dependsOn ...string) Option {
+	return func(o *options) {
+		o.components = append(o.components, &component{Component: c, dependsOn: dependsOn})
+	}
+}
+
+// sortComponents groups components into levels, every component only depends
+// on components of the former levels, so a level can be started concurrently.
+func sortComponents(components []*component) ([][]*component, error) {
+	byName := make(map[string]*component, len(components))
+	for _, c := range components {
+		if _, ok := byName[c.Name()]; ok {
+			return nil, fmt.Errorf("duplicate component %q", c.Name())
+		}
+		byName[c.Name()] = c
+	}
+	inDegree := make(map[string]int, len(components))
+	dependents := make(map[string][]*component, len(components))
+	for _, c := range components {
+		for _, dep := range c.dependsOn {
+			if _, ok := byName[dep]; !ok {
+				return nil, fmt.Errorf("component %q depends on unknown component %q", c.Name(), dep)
+			}
+			inDegree[c.Name()]++
+			dependents[dep] = append(dependents[dep], c)
+		}
+	}
+
+	var levels [][]*component
+	var level []*component
+	for _, c := range components {
+		if inDegree[c.Name()] == 0 {
+			level = append(level, c)
+		}
+	}
+	sorted := 0
+	for len(level) > 0 {
+		levels = append(levels, level)
+		sorted += len(level)
+		var next []*component
+		for _, c := range level {
+			for _, d := range dependents[c.Name()] {
+				if inDegree[d.Name()]--; inDegree[d.Name()] == 0 {
+					next = append(next, d)
+				}
+			}
+		}
+		level = next
+	}
+	if sorted != len(components) {
+		var cycle []string
+		for name, n := range inDegree {
+			if n > 0 {
+				cycle = append(cycle, name)
+			}
+		}
+		sort.Strings(cycle)
+		return nil, fmt.Errorf("dependency cycle between components: %s", strings.Join(cycle, ", "))
+	}
+	return levels, nil
+}
+
+// startComponents starts the levels in order and the components of a level
+// concurrently. On failure the started components are stopped in reverse order.
+func (a *App) startComponents(ctx context.Context) error {
+	for i, level := range a.levels {
+		started, err := runLevel(level, func(c *component) error {
+			if err := c.Start(ctx); err != nil {
+				return fmt.Errorf("error start component(%s): %w", c.Name(), err)
+			}
+			return nil
+		})
+		if err != nil {
+			stopCtx, cancel := context.WithTimeout(context.Background(), a.opts.stopTimeout)
+			defer cancel()
+			return multierr.Append(err, stopLevels(stopCtx, append(a.levels[:i:i], started)))
+		}
+	}
+	return nil
+}
+
+// stopComponents stops all components, see stopLevels.
+func (a *App) stopComponents(ctx context.Context) error {
+	return stopLevels(ctx, a.levels)
+}
+
+// stopLevels stops the levels in reverse order and the components of a
+// level concurrently, the returned error combines all stop errors.
+func stopLevels(ctx context.Context, levels [][]*component) error {
+	var err error
+	for i := len(levels) - 1; i >= 0; i-- {
+		_, e := runLevel(levels[i], func(c *component) error {
+			if err := c.Stop(ctx); err != nil {
+				return fmt.Errorf("error stop component(%s): %w", c.Name(), err)
+			}
+			return nil
+		})
+		err = multierr.Append(err, e)
+	}
+	return err
+}
+
+// runLevel calls fn for every component concurrently and returns the
+// components fn succeeded for.
+func runLevel(level []*component, fn func(c *component) error) ([]*component, error) {
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		ok  []*component
+		err error
+	)
+	for _, c := range level {
+		wg.Add(1)
+		go func(c *component) {
+			defer wg.Done()
+			e := fn(c)
+			mu.Lock()
+			defer mu.Unlock()
+			if e != nil {
+				err = multierr.Append(err, e)
+				return
+			}
+			ok = append(ok, c)
+		}(c)
+	}
+	wg.Wait()
+	return ok, err
+}
@@ -0,0 +1,138 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testComponent struct {
+	name     string
+	startErr error
+	start    func()
+	mu       *sync.Mutex
+	events   *[]string
+}
+
+func (c *testComponent) Name() string {
+	return c.name
+}
+
+func (c *testComponent) Start(ctx context.Context) error {
+	if c.start != nil {
+		c.start()
+	}
+	if c.startErr != nil {
+		return c.startErr
+	}
+	c.record("start")
+	return nil
+}
+
+func (c *testComponent) Stop(ctx context.Context) error {
+	c.record("stop")
+	return nil
+}
+
+func (c *testComponent) record(event string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.events = append(*c.events, event+" "+c.name)
+}
+
+func TestComponentOrder(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	newComponent := func(name string) *testComponent {
+		return &testComponent{name: name, mu: &mu, events: &events}
+	}
+	a, err := New("test",
+		WithComponent(newComponent("consumer"), "cache", "db"),
+		WithComponent(newComponent("cache"), "db"),
+		WithComponent(newComponent("db")),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, a.Start(context.Background()))
+	require.NoError(t, a.Stop(context.Background()))
+	assert.Equal(t, []string{
+		"start db", "start cache", "start consumer",
+		"stop consumer", "stop cache", "stop db",
+	}, events)
+}
+
+func TestComponentConcurrentStart(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []string
+		wg     sync.WaitGroup
+	)
+	// both components block until the other one is starting.
+	wg.Add(2)
+	barrier := func() {
+		wg.Done()
+		wg.Wait()
+	}
+	a, err := New("test",
+		WithComponent(&testComponent{name: "a", start: barrier, mu: &mu, events: &events}),
+		WithComponent(&testComponent{name: "b", start: barrier, mu: &mu, events: &events}),
+	)
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() { done <- a.Start(context.Background()) }()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("independent components are not started concurrently")
+	}
+}
+
+func TestComponentRollback(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	errStart := errors.New("start")
+	a, err := New("test",
+		WithComponent(&testComponent{name: "db", mu: &mu, events: &events}),
+		WithComponent(&testComponent{name: "cache", startErr: errStart, mu: &mu, events: &events}, "db"),
+		WithComponent(&testComponent{name: "consumer", mu: &mu, events: &events}, "cache"),
+	)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, a.Start(context.Background()), errStart)
+	assert.Equal(t, []string{"start db", "stop db"}, events)
+}
+
+func TestComponentInvalid(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	newComponent := func(name string) *testComponent {
+		return &testComponent{name: name, mu: &mu, events: &events}
+	}
+
+	_, err := New("test",
+		WithComponent(newComponent("a"), "c"),
+		WithComponent(newComponent("b"), "a"),
+		WithComponent(newComponent("c"), "b"),
+		WithComponent(newComponent("d")),
+	)
+	assert.EqualError(t, err, "error new app: dependency cycle between components: a, b, c")
+
+	_, err = New("test", WithComponent(newComponent("a"), "b"))
+	assert.Error(t, err)
+
+	_, err = New("test", WithComponent(newComponent("a")), WithComponent(newComponent("a")))
+	assert.Error(t, err)
+}
@@ -0,0 +1,27 @@
+package app
+
+import (
+	"context"
+)
+
+// Info is the identity of a running application.
+type Info interface {
+	ID() string
+	Name() string
+	Version() string
+	Metadata() map[string]string
+}
+
+type appKey struct{}
+
+// NewContext returns a new Context that carries the application info.
+func NewContext(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, appKey{}, info)
+}
+
+// FromContext returns the application info stored in ctx, if any.
+// It is available in every HTTP and gRPC handler of the app's servers.
+func FromContext(ctx context.Context) (Info, bool) {
+	info, ok := ctx.Value(appKey{}).(Info)
+	return info, ok
+}
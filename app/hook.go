@@ -0,0 +1,56 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/multierr"
+)
+
+// Hook is a function called at a stage of the app lifecycle.
+type Hook func(ctx context.Context) error
+
+// WithBeforeStart appends hooks called before the servers start,
+// a failing hook aborts the startup.
+func WithBeforeStart(h ...Hook) Option {
+	return func(o *options) { o.beforeStart = append(o.beforeStart, h...) }
+}
+
+// WithAfterStart appends hooks called after all servers started,
+// a failing hook stops the started servers and aborts the startup.
+func WithAfterStart(h ...Hook) Option {
+	return func(o *options) { o.afterStart = append(o.afterStart, h...) }
+}
+
+// WithBeforeStop appends hooks called before the servers stop,
+// a failing hook is reported but does not prevent the shutdown.
+func WithBeforeStop(h ...Hook) Option {
+	return func(o *options) { o.beforeStop = append(o.beforeStop, h...) }
+}
+
+// WithAfterStop appends hooks called after all servers stopped,
+// a failing hook is reported but does not block the other hooks.
+func WithAfterStop(h ...Hook) Option {
+	return func(o *options) { o.afterStop = append(o.afterStop, h...) }
+}
+
+// runHooks calls hooks in order and returns the first error.
+func runHooks(ctx context.Context, stage string, hooks []Hook) error {
+	for i, h := range hooks {
+		if err := h(ctx); err != nil {
+			return fmt.Errorf("error %s hook(%d): %w", stage, i, err)
+		}
+	}
+	return nil
+}
+
+// runAllHooks calls all hooks in order and combines their errors.
+func runAllHooks(ctx context.Context, stage string, hooks []Hook) error {
+	var err error
+	for i, h := range hooks {
+		if e := h(ctx); e != nil {
+			err = multierr.Append(err, fmt.Errorf("error %s hook(%d): %w", stage, i, e))
+		}
+	}
+	return err
+}
@@ -0,0 +1,81 @@
+package app
+
+import (
+	"os"
+	"time"
+
+	"github.com/tkeel-io/kit/log"
+	"github.com/tkeel-io/kit/registry"
+	"github.com/tkeel-io/kit/transport"
+)
+
+// Option is an application option.
+type Option func(o *options)
+
+type options struct {
+	id            string
+	name          string
+	version       string
+	logConf       *log.Conf
+	servers       []transport.Server
+	stopTimeout   time.Duration
+	metadata      map[string]string
+	signals       []os.Signal
+	restartSignal os.Signal
+	components    []*component
+	registrar     registry.Registrar
+
+	beforeStart []Hook
+	afterStart  []Hook
+	beforeStop  []Hook
+	afterStop   []Hook
+}
+
+// WithID sets the app instance ID, a random ID is generated by default.
+func WithID(id string) Option {
+	return func(o *options) { o.id = id }
+}
+
+// WithVersion sets the app version, version.Version is used by default.
+func WithVersion(v string) Option {
+	return func(o *options) { o.version = v }
+}
+
+// WithLogger initializes the global logger with conf when creating the app.
+func WithLogger(conf *log.Conf) Option {
+	return func(o *options) { o.logConf = conf }
+}
+
+// WithServer appends transport servers to the app.
+func WithServer(srv ...transport.Server) Option {
+	return func(o *options) { o.servers = append(o.servers, srv...) }
+}
+
+// WithStopTimeout sets the deadline for stopping the app in Run.
+func WithStopTimeout(d time.Duration) Option {
+	return func(o *options) { o.stopTimeout = d }
+}
+
+// WithMetadata sets the app metadata.
+func WithMetadata(md map[string]string) Option {
+	return func(o *options) { o.metadata = md }
+}
+
+// WithGracefulRestart enables restarting without dropping connections when
+// sig is received, see the handoff package. The servers must listen through
+// handoff.Listen, as the servers of the transport packages do. SIGTERM
+// always stops the app then, since the new process stops the old one by it.
+func WithGracefulRestart(sig os.Signal) Option {
+	return func(o *options) { o.restartSignal = sig }
+}
+
+// WithRegistrar sets the registrar which the app registers its server
+// endpoints to after start and deregisters them from before stop.
+func WithRegistrar(r registry.Registrar) Option {
+	return func(o *options) { o.registrar = r }
+}
+
+// WithSignal sets the OS signals which make Run shut the app down.
+func WithSignal(sigs ...os.Signal) Option {
+	return func(o *options) { o.signals = sigs }
+}
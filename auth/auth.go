@@ -38,18 +38,23 @@ func Authenticate(token interface{}, urls ...string) (*User, error) {
 		tokenStr = t
 	case context.Context:
 		val, ok := transportHTTP.HeaderFromContext(t)[_Authorization]
-		if !ok || len(tokenStr) == 0 {
+		if !ok || len(val) == 0 {
 			return nil, errors.New("invalid Authenticate")
 		}
 		tokenStr = val[0]
 	default:
 		return nil, errors.New("invalid token type")
 	}
+	return authenticate(_auth, tokenStr)
+}
+
+// authenticate asks the auth service at url for the user of tokenStr.
+func authenticate(url, tokenStr string) (*User, error) {
 	if tokenStr == "" {
 		return nil, errors.New("token is empty")
 	}
 
-	req, err := http.NewRequest("GET", _auth, nil)
+	req, err := http.NewRequest("GET", url, nil)
 	if nil != err {
 		return nil, err
 	}
@@ -1,9 +1,16 @@
 package auth
 
 import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/emicklei/go-restful"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	transportHTTP "github.com/tkeel-io/kit/transport/http"
 )
 
 func TestAuthenticate(t *testing.T) {
@@ -12,3 +19,92 @@ func TestAuthenticate(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotEmpty(t, auth)
 }
+
+func TestAuthenticateContext(t *testing.T) {
+	saved := _auth
+	t.Cleanup(func() { _auth = saved })
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get(_Authorization))
+		_, _ = w.Write([]byte(`{"code":"io.tkeel.SUCCESS","data":{"user_id":"u1","tenant_id":"t1"}}`))
+	}))
+	defer srv.Close()
+
+	h := http.Header{}
+	h.Set(_Authorization, "Bearer token")
+	u, err := Authenticate(transportHTTP.ContextWithHeader(context.Background(), h), srv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, &User{ID: "u1", TenantID: "t1", Token: "Bearer token"}, u)
+
+	_, err = Authenticate(context.Background(), srv.URL)
+	assert.Error(t, err)
+}
+
+func TestTrackUser(t *testing.T) {
+	ctx, user := TrackUser(context.Background())
+	_, ok := user()
+	assert.False(t, ok)
+	u := &User{ID: "u1"}
+	inner := ContextWithUser(ctx, u)
+	got, ok := UserFromContext(inner)
+	require.True(t, ok)
+	assert.Equal(t, u, got)
+	got, ok = user()
+	require.True(t, ok)
+	assert.Equal(t, u, got)
+}
+
+func TestMiddleware(t *testing.T) {
+	authSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(_Authorization) != "Bearer token" {
+			_, _ = w.Write([]byte(`{"code":"io.tkeel.UNAUTHENTICATED","msg":"invalid token"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":"io.tkeel.SUCCESS","data":{"user_id":"u1","tenant_id":"t1"}}`))
+	}))
+	defer authSrv.Close()
+
+	s := transportHTTP.NewServer("127.0.0.1:0", transportHTTP.WithMiddleware(Middleware(authSrv.URL)))
+	ws := new(restful.WebService)
+	ws.Route(ws.GET("/user").To(func(req *restful.Request, resp *restful.Response) {
+		u, ok := UserFromContext(req.Request.Context())
+		if !ok {
+			resp.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = resp.Write([]byte(u.ID + "/" + u.TenantID))
+	}))
+	s.Container.Add(ws)
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+
+	for token, want := range map[string]int{"": http.StatusUnauthorized, "Bearer bad": http.StatusUnauthorized, "Bearer token": http.StatusOK} {
+		req, err := http.NewRequest(http.MethodGet, u.String()+"/user", nil)
+		require.NoError(t, err)
+		req.Header.Set(_Authorization, token)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		b, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+		assert.Equal(t, want, resp.StatusCode, token)
+		if want == http.StatusOK {
+			assert.Equal(t, "u1/t1", string(b))
+		}
+	}
+}
+
+func TestTrackUserConcurrent(t *testing.T) {
+	ctx, user := TrackUser(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ContextWithUser(ctx, &User{ID: "u1"})
+	}()
+	user()
+	<-done
+	got, ok := user()
+	require.True(t, ok)
+	assert.Equal(t, "u1", got.ID)
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"sync"
+)
+
+type userKey struct{}
+
+type trackerKey struct{}
+
+// userTracker records the user stored by ContextWithUser in a descendant context.
+type userTracker struct {
+	mu   sync.Mutex
+	user *User
+}
+
+func (t *userTracker) set(u *User) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.user = u
+}
+
+func (t *userTracker) get() (*User, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.user, t.user != nil
+}
+
+// ContextWithUser returns a new context carrying the authenticated user,
+// the user is also reported to the TrackUser of an ancestor context.
+func ContextWithUser(ctx context.Context, u *User) context.Context {
+	if t, ok := ctx.Value(trackerKey{}).(*userTracker); ok {
+		t.set(u)
+	}
+	return context.WithValue(ctx, userKey{}, u)
+}
+
+// UserFromContext returns the authenticated user stored in ctx.
+func UserFromContext(ctx context.Context) (*User, bool) {
+	u, ok := ctx.Value(userKey{}).(*User)
+	return u, ok
+}
+
+// TrackUser returns a context for the inner handlers of a request, and a
+// function returning the user they store with ContextWithUser, so that an
+// outer middleware such as the access log knows the user.
+func TrackUser(ctx context.Context) (context.Context, func() (*User, bool)) {
+	if u, ok := UserFromContext(ctx); ok {
+		return ctx, func() (*User, bool) { return u, true }
+	}
+	t := &userTracker{}
+	ctx = context.WithValue(ctx, trackerKey{}, t)
+	return ctx, t.get
+}
@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"context"
+
+	kerrors "github.com/tkeel-io/kit/errors"
+	"github.com/tkeel-io/kit/log"
+	"github.com/tkeel-io/kit/middleware"
+	"github.com/tkeel-io/kit/transport"
+)
+
+// Middleware returns a middleware which authenticates the Authorization
+// header of the request and stores the user in the context of the handler,
+// see UserFromContext. It replies errors.Unauthenticated if the
+// authentication fails. The auth service is urls[0] if given, or the one
+// of the last Authenticate call before Middleware.
+func Middleware(urls ...string) middleware.Middleware {
+	url := _auth
+	if len(urls) > 0 {
+		url = urls[0]
+	}
+	return func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			var token string
+			if tr, ok := transport.FromServerContext(ctx); ok {
+				token = tr.RequestHeader().Get(_Authorization)
+			}
+			u, err := authenticate(url, token)
+			if err != nil {
+				log.Debugf("error authenticate: %s", err)
+				return nil, kerrors.Unauthenticated
+			}
+			return next(ContextWithUser(ctx, u), req)
+		}
+	}
+}
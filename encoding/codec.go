@@ -0,0 +1,139 @@
+package encoding
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+// Content types of the registered codecs.
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeYAML     = "application/yaml"
+	ContentTypeForm     = "application/x-www-form-urlencoded"
+)
+
+// Codec marshals and unmarshals the bodies of a content type.
+// proto.Message fields are named by their JSON names in every text codec.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{
+		ContentTypeJSON:        JSONCodec{},
+		ContentTypeProtobuf:    ProtoCodec{},
+		"application/protobuf": ProtoCodec{},
+		ContentTypeYAML:        YAMLCodec{},
+		"application/x-yaml":   YAMLCodec{},
+		"text/yaml":            YAMLCodec{},
+		ContentTypeForm:        NewCodec(),
+	}
+)
+
+// RegisterCodec registers c for contentType, overriding the existing one.
+func RegisterCodec(contentType string, c Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[contentType] = c
+}
+
+// CodecFor returns the codec of contentType, its parameters such as
+// charset are ignored.
+func CodecFor(contentType string) (Codec, bool) {
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	c, ok := codecs[strings.ToLower(strings.TrimSpace(contentType))]
+	return c, ok
+}
+
+// JSONCodec encodes proto.Message with protojson and the others with
+// encoding/json, whose numbers decoded into interface{} are json.Number,
+// so that large integers are not rounded to float64.
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	if m, ok := v.(proto.Message); ok {
+		return protojson.Marshal(m)
+	}
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	if m, ok := v.(proto.Message); ok {
+		return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, m)
+	}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	return d.Decode(v)
+}
+
+// ProtoCodec encodes proto.Message in the protobuf wire format.
+type ProtoCodec struct{}
+
+func (ProtoCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+func (ProtoCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%T is not a proto.Message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+// YAMLCodec encodes proto.Message as its protojson document
+// and the others with yaml.v3.
+type YAMLCodec struct{}
+
+func (YAMLCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return yaml.Marshal(v)
+	}
+	b, err := protojson.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(doc)
+}
+
+func (YAMLCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return yaml.Unmarshal(data, v)
+	}
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if doc == nil {
+		return nil
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(b, m)
+}
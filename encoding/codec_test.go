@@ -1,10 +1,16 @@
 package encoding
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"github.com/tkeel-io/kit/encoding/testdata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/apipb"
+	"google.golang.org/protobuf/types/known/sourcecontextpb"
+	"google.golang.org/protobuf/types/known/typepb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 type LoginRequest struct {
@@ -77,3 +83,43 @@ func TestProtoEncodeDecode(t *testing.T) {
 	require.Equal(t, false, in2.C)
 	require.Equal(t, float32(4.4), in2.D)
 }
+
+func TestCodecFor(t *testing.T) {
+	c, ok := CodecFor("application/json; charset=utf-8")
+	require.True(t, ok)
+	require.Equal(t, JSONCodec{}, c)
+	_, ok = CodecFor("application/unknown")
+	require.False(t, ok)
+}
+
+func TestCodecs(t *testing.T) {
+	in := &apipb.Api{Name: "api", Syntax: typepb.Syntax_SYNTAX_PROTO3, SourceContext: &sourcecontextpb.SourceContext{FileName: "a.proto"}}
+
+	b, err := JSONCodec{}.Marshal(in)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"name":"api","syntax":"SYNTAX_PROTO3","sourceContext":{"fileName":"a.proto"}}`, string(b))
+	b, err = JSONCodec{}.Marshal(wrapperspb.Int64(1))
+	require.NoError(t, err)
+	require.Equal(t, `"1"`, string(b))
+
+	b, err = YAMLCodec{}.Marshal(in)
+	require.NoError(t, err)
+	require.Equal(t, "name: api\nsourceContext:\n    fileName: a.proto\nsyntax: SYNTAX_PROTO3\n", string(b))
+
+	for _, c := range []Codec{JSONCodec{}, ProtoCodec{}, YAMLCodec{}} {
+		b, err := c.Marshal(in)
+		require.NoError(t, err)
+		out := new(apipb.Api)
+		require.NoError(t, c.Unmarshal(b, out))
+		require.True(t, proto.Equal(in, out), "%T", c)
+	}
+
+	_, err = ProtoCodec{}.Marshal(&LoginRequest{})
+	require.Error(t, err)
+}
+
+func TestJSONCodecNumber(t *testing.T) {
+	var out map[string]interface{}
+	require.NoError(t, JSONCodec{}.Unmarshal([]byte(`{"id":9007199254740993}`), &out))
+	require.Equal(t, json.Number("9007199254740993"), out["id"])
+}
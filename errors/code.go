@@ -74,10 +74,13 @@ const (
 	SUCCESS_CODE  = "io.tkeel.SUCCESS"
 	INTERNAL_CODE = "io.tkeel.INTERNAL_ERROR"
 	REDIRECT_CODE = "io.tkeel.REDIRECT"
+
+	UNAUTHENTICATED_CODE = "io.tkeel.UNAUTHENTICATED"
 )
 
 var InternalError = New(int(codes.Internal), INTERNAL_CODE, "")
 var Success = New(int(codes.OK), SUCCESS_CODE, "")
+var Unauthenticated = New(int(codes.Unauthenticated), UNAUTHENTICATED_CODE, "")
 
 func NewRedirect(location string) *TError {
 	return New(int(codes.DataLoss), REDIRECT_CODE, location)
@@ -0,0 +1,26 @@
+package ctxutil
+
+import (
+	"context"
+)
+
+type valuesContext struct {
+	context.Context
+	values context.Context
+}
+
+// WithValues returns a context which has the deadline and cancellation of
+// parent, and looks up values in parent first and then in values.
+func WithValues(parent, values context.Context) context.Context {
+	if values == nil {
+		return parent
+	}
+	return &valuesContext{Context: parent, values: values}
+}
+
+func (c *valuesContext) Value(key interface{}) interface{} {
+	if v := c.Context.Value(key); v != nil {
+		return v
+	}
+	return c.values.Value(key)
+}
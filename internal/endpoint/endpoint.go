@@ -0,0 +1,81 @@
+package endpoint
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const unixScheme = "unix://"
+
+// ParseAddr returns the network and the address of a server address,
+// unix:///path/to/socket is a unix domain socket and the others are TCP.
+func ParseAddr(addr string) (network, address string) {
+	if strings.HasPrefix(addr, unixScheme) {
+		return "unix", strings.TrimPrefix(addr, unixScheme)
+	}
+	return "tcp", addr
+}
+
+// URL returns the URL with scheme of a server listening on l. When l listens
+// on an unspecified address, the host is the first private IP of the machine.
+// The URL of a unix domain socket is unix:///path/to/socket.
+func URL(scheme string, l net.Listener) (*url.URL, error) {
+	if l == nil {
+		return nil, errors.New("server is not started")
+	}
+	if l.Addr().Network() == "unix" {
+		return &url.URL{Scheme: "unix", Path: l.Addr().String()}, nil
+	}
+	host, port, err := net.SplitHostPort(l.Addr().String())
+	if err != nil {
+		return nil, fmt.Errorf("error split listen addr: %w", err)
+	}
+	if ip := net.ParseIP(host); ip == nil || ip.IsUnspecified() {
+		if host, err = privateIP(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := strconv.Atoi(port); err != nil {
+		return nil, fmt.Errorf("error parse listen port: %w", err)
+	}
+	return &url.URL{Scheme: scheme, Host: net.JoinHostPort(host, port)}, nil
+}
+
+// privateIP returns the first private IPv4 address of the up interfaces,
+// or the loopback address when there is none.
+func privateIP() (string, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return "", fmt.Errorf("error get interfaces: %w", err)
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			if ip := ipNet.IP.To4(); ip != nil && isPrivate(ip) {
+				return ip.String(), nil
+			}
+		}
+	}
+	return "127.0.0.1", nil
+}
+
+// isPrivate reports whether ip is in 10.0.0.0/8, 172.16.0.0/12 or 192.168.0.0/16.
+func isPrivate(ip net.IP) bool {
+	return ip[0] == 10 ||
+		ip[0] == 172 && ip[1]&0xf0 == 16 ||
+		ip[0] == 192 && ip[1] == 168
+}
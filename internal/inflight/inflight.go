@@ -0,0 +1,54 @@
+// Package inflight counts the running operations of a server,
+// so that it can wait for them on stop.
+package inflight
+
+import (
+	"context"
+	"sync"
+)
+
+// Counter counts the running operations, the zero value is ready to use.
+// Unlike sync.WaitGroup, Add may be called while Wait is waiting.
+type Counter struct {
+	mu   sync.Mutex
+	n    int
+	zero chan struct{}
+}
+
+// Add records an operation started.
+func (c *Counter) Add() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.n++
+}
+
+// Done records an operation finished.
+func (c *Counter) Done() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.n--
+	if c.n == 0 && c.zero != nil {
+		close(c.zero)
+		c.zero = nil
+	}
+}
+
+// Wait waits until no operation runs or ctx is done.
+func (c *Counter) Wait(ctx context.Context) error {
+	c.mu.Lock()
+	if c.n == 0 {
+		c.mu.Unlock()
+		return nil
+	}
+	if c.zero == nil {
+		c.zero = make(chan struct{})
+	}
+	zero := c.zero
+	c.mu.Unlock()
+	select {
+	case <-zero:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
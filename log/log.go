@@ -47,8 +47,13 @@ var (
 	Fatalw  func(msg string, keysAndValues ...interface{})
 )
 
+// atomicLevel is the level of the global logger.
+var atomicLevel = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+
 func init() {
-	globalLogger, _ := zap.NewDevelopment()
+	c := zap.NewDevelopmentConfig()
+	c.Level = atomicLevel
+	globalLogger, _ := c.Build()
 	zap.ReplaceGlobals(globalLogger)
 	syncLogS()
 }
@@ -103,7 +108,7 @@ func InitLogger(app string, level string, dev bool, output ...string) error {
 	if dev {
 		c.Encoding = "console"
 	}
-	c.Level = getLevel(level)
+	c.Level = atomicLevel
 	if c.InitialFields == nil {
 		c.InitialFields = make(map[string]interface{})
 	}
@@ -119,6 +124,7 @@ func InitLogger(app string, level string, dev bool, output ...string) error {
 	if err != nil {
 		return fmt.Errorf("error build zap log: %w", err)
 	}
+	atomicLevel.SetLevel(getLevel(level).Level())
 	resetGlobalFunc = zap.ReplaceGlobals(logger)
 	syncLogS()
 	return nil
@@ -151,6 +157,12 @@ func getLevel(level string) zap.AtomicLevel {
 	return zap.NewAtomicLevelAt(zapcore.InfoLevel)
 }
 
+// AtomicLevel returns the level of the global logger, which can be changed
+// at runtime, it also serves HTTP GET and PUT requests for the level.
+func AtomicLevel() zap.AtomicLevel {
+	return atomicLevel
+}
+
 func Check(lvl zapcore.Level, msg string) *zapcore.CheckedEntry {
 	return zap.L().Check(lvl, msg)
 }
@@ -16,6 +16,20 @@ limitations under the License.
 
 package log
 
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestInitLoggerBuildError(t *testing.T) {
+	lvl := AtomicLevel().Level()
+	assert.Error(t, InitLogger("app", "error", false, "/nonexistent/dir/app.log"))
+	assert.Equal(t, lvl, AtomicLevel().Level())
+	assert.NotEqual(t, zapcore.ErrorLevel, lvl)
+}
+
 func a() {
 	L().Debug("a")
 	b()
@@ -0,0 +1,189 @@
+// Package accesslog provides a middleware logging one entry per request
+// of the HTTP routes and the gRPC unary and stream methods.
+package accesslog
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"path"
+	"sync/atomic"
+	"time"
+
+	"github.com/tkeel-io/kit/auth"
+	"github.com/tkeel-io/kit/errors"
+	"github.com/tkeel-io/kit/log"
+	"github.com/tkeel-io/kit/middleware"
+	"github.com/tkeel-io/kit/transport"
+	transportHTTP "github.com/tkeel-io/kit/transport/http"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Option is an access log option.
+type Option func(o *options)
+
+type options struct {
+	logger     *zap.Logger
+	sampleRate float64
+	excludes   []string
+}
+
+// WithLogger sets the logger of the entries, log.L() by default.
+func WithLogger(l *zap.Logger) Option {
+	return func(o *options) { o.logger = l }
+}
+
+// WithSampleRate logs the successful requests with probability r in
+// [0, 1], 1 by default. Failed requests are always logged.
+func WithSampleRate(r float64) Option {
+	return func(o *options) { o.sampleRate = r }
+}
+
+// WithExcludes skips the requests whose route, HTTP path or gRPC method
+// matches one of patterns, in the syntax of path.Match, such as "/healthz"
+// or "/grpc.health.v1.Health/*".
+func WithExcludes(patterns ...string) Option {
+	return func(o *options) { o.excludes = append(o.excludes, patterns...) }
+}
+
+// AccessLog returns a middleware which logs each request with its method,
+// route, status, latency, reply bytes, peer, user, tenant and error reason.
+// The status of an HTTP request is the HTTP status and the one of a gRPC
+// request is the gRPC code. The reply bytes of a gRPC stream are the sizes
+// of the sent messages, the ones of a failed HTTP request do not count the
+// error envelope, which the server writes after the middleware returns.
+// The user is the one stored by the inner handlers with
+// auth.ContextWithUser, so AccessLog is best installed first.
+func AccessLog(opts ...Option) middleware.Middleware {
+	o := options{sampleRate: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok || o.excluded(tr) {
+				return next(ctx, req)
+			}
+			start := time.Now()
+			ctx, user := auth.TrackUser(ctx)
+			var sent *sizeStream
+			if ss, ok := req.(grpc.ServerStream); ok {
+				sent = &sizeStream{ServerStream: ss}
+				req = sent
+			}
+			reply, err := next(ctx, req)
+
+			e := newEntry(ctx, tr, reply, err)
+			if sent != nil {
+				e.bytes = int(atomic.LoadInt64(&sent.bytes))
+			}
+			if e.httpStatus < http.StatusBadRequest && o.sampleRate < 1 && rand.Float64() >= o.sampleRate { //nolint:gosec
+				return reply, err
+			}
+			fields := []zap.Field{
+				zap.String("kind", string(tr.Kind())),
+				zap.String("method", e.method),
+				zap.String("route", tr.Operation()),
+				zap.Int("status", e.status),
+				zap.Duration("latency", time.Since(start)),
+				zap.Int("bytes", e.bytes),
+				zap.String("peer", e.peer),
+			}
+			if u, ok := user(); ok {
+				fields = append(fields, zap.String("user", u.ID), zap.String("tenant", u.TenantID))
+			}
+			if err != nil {
+				fields = append(fields, zap.String("reason", errors.FromError(err).Reason))
+			}
+			level := zapcore.InfoLevel
+			if e.httpStatus >= http.StatusInternalServerError {
+				level = zapcore.ErrorLevel
+			}
+			if ce := o.log().Check(level, "access"); ce != nil {
+				ce.Write(fields...)
+			}
+			return reply, err
+		}
+	}
+}
+
+func (o *options) log() *zap.Logger {
+	if o.logger != nil {
+		return o.logger
+	}
+	return log.L()
+}
+
+func (o *options) excluded(tr transport.Transporter) bool {
+	names := []string{tr.Operation()}
+	if ht, ok := tr.(*transportHTTP.Transport); ok && ht.Request() != nil {
+		names = append(names, ht.Request().Request.URL.Path)
+	}
+	for _, pattern := range o.excludes {
+		for _, name := range names {
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sizeStream counts the bytes of the messages sent on a gRPC stream.
+type sizeStream struct {
+	grpc.ServerStream
+	bytes int64
+}
+
+func (s *sizeStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if msg, ok := m.(proto.Message); ok && err == nil {
+		atomic.AddInt64(&s.bytes, int64(proto.Size(msg)))
+	}
+	return err
+}
+
+// entry is the part of an access log entry which depends on the transport.
+type entry struct {
+	method string
+	status int
+	// httpStatus is the status or its HTTP equivalent.
+	httpStatus int
+	bytes      int
+	peer       string
+}
+
+func newEntry(ctx context.Context, tr transport.Transporter, reply interface{}, err error) entry {
+	var e entry
+	if ht, ok := tr.(*transportHTTP.Transport); ok {
+		req, resp := ht.Request(), ht.Response()
+		e.method = req.Request.Method
+		e.peer = req.Request.RemoteAddr
+		e.bytes = resp.ContentLength()
+		if err != nil {
+			e.httpStatus = errors.FromError(err).ToHTTPStatusCode()
+		} else {
+			e.httpStatus = resp.StatusCode()
+		}
+		e.status = e.httpStatus
+		return e
+	}
+
+	e.method = tr.Operation()
+	code := status.Code(err)
+	e.status = int(code)
+	e.httpStatus = errors.GRPCToHTTPStatusCode(code)
+	if m, ok := reply.(proto.Message); ok {
+		e.bytes = proto.Size(m)
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		e.peer = p.Addr.String()
+	}
+	return e
+}
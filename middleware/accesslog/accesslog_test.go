@@ -0,0 +1,133 @@
+package accesslog
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tkeel-io/kit/auth"
+	"github.com/tkeel-io/kit/errors"
+	"github.com/tkeel-io/kit/middleware"
+	"github.com/tkeel-io/kit/transport"
+	transportGRPC "github.com/tkeel-io/kit/transport/grpc"
+	transportHTTP "github.com/tkeel-io/kit/transport/http"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func authenticate(next middleware.Handler) middleware.Handler {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		return next(auth.ContextWithUser(ctx, &auth.User{ID: "u1", TenantID: "t1"}), req)
+	}
+}
+
+func TestAccessLogHTTP(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	s := transportHTTP.NewServer("127.0.0.1:0", transportHTTP.WithMiddleware(
+		AccessLog(WithLogger(zap.New(core)), WithExcludes("/healthz")),
+		authenticate,
+	))
+	ws := new(restful.WebService)
+	ws.Route(ws.GET("/users/{id}").To(func(req *restful.Request, resp *restful.Response) {
+		resp.WriteHeader(http.StatusCreated)
+		_, _ = resp.Write([]byte("hello"))
+	}))
+	ws.Route(ws.GET("/healthz").To(func(req *restful.Request, resp *restful.Response) {}))
+	s.Container.Add(ws)
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+
+	for _, path := range []string{"/users/1", "/healthz"} {
+		resp, err := http.Get(u.String() + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "access", entries[0].Message)
+	assert.Equal(t, string(transport.TypeHTTP), fields["kind"])
+	assert.Equal(t, http.MethodGet, fields["method"])
+	assert.Equal(t, "/users/{id}", fields["route"])
+	assert.Equal(t, int64(http.StatusCreated), fields["status"])
+	assert.Equal(t, int64(5), fields["bytes"])
+	assert.NotEmpty(t, fields["peer"])
+	assert.Equal(t, "u1", fields["user"])
+	assert.Equal(t, "t1", fields["tenant"])
+	assert.Contains(t, fields, "latency")
+	assert.NotContains(t, fields, "reason")
+}
+
+func TestAccessLogGRPC(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	s := transportGRPC.NewServer("127.0.0.1:0", transportGRPC.WithMiddleware(
+		AccessLog(WithLogger(zap.New(core)), WithSampleRate(0)),
+	))
+	grpc_health_v1.RegisterHealthServer(s.GetServe(), health.NewServer())
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+
+	conn, err := grpc.Dial(u.Host, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+	client := grpc_health_v1.NewHealthClient(conn)
+	// Successful requests are sampled out.
+	_, err = client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "unknown"})
+	require.Error(t, err)
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, string(transport.TypeGRPC), fields["kind"])
+	assert.Equal(t, "/grpc.health.v1.Health/Check", fields["method"])
+	assert.Equal(t, int64(codes.NotFound), fields["status"])
+	assert.Equal(t, errors.UnknownReason, fields["reason"])
+	assert.NotEmpty(t, fields["peer"])
+}
+
+func TestAccessLogGRPCStream(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	s := transportGRPC.NewServer("127.0.0.1:0", transportGRPC.WithMiddleware(
+		AccessLog(WithLogger(zap.New(core))),
+		authenticate,
+	))
+	grpc_health_v1.RegisterHealthServer(s.GetServe(), health.NewServer())
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+
+	conn, err := grpc.Dial(u.Host, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := grpc_health_v1.NewHealthClient(conn).Watch(ctx, &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+	reply, err := stream.Recv()
+	require.NoError(t, err)
+	cancel()
+
+	require.Eventually(t, func() bool { return logs.Len() == 1 }, time.Second, 10*time.Millisecond)
+	fields := logs.AllUntimed()[0].ContextMap()
+	assert.Equal(t, "/grpc.health.v1.Health/Watch", fields["method"])
+	assert.Equal(t, int64(codes.Canceled), fields["status"])
+	assert.Equal(t, int64(proto.Size(reply)), fields["bytes"])
+	assert.Equal(t, "u1", fields["user"])
+}
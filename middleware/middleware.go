@@ -0,0 +1,26 @@
+// Package middleware defines transport-neutral middleware, which the HTTP
+// and gRPC servers install with their WithMiddleware options.
+package middleware
+
+import (
+	"context"
+)
+
+// Handler handles a request. The req of the HTTP server is the
+// *restful.Request and its reply is nil, the req and reply of the gRPC
+// server are the request and reply messages of a unary method, or the
+// grpc.ServerStream and nil for a stream method.
+type Handler func(ctx context.Context, req interface{}) (interface{}, error)
+
+// Middleware wraps a Handler.
+type Middleware func(Handler) Handler
+
+// Chain returns a Middleware calling m in order, the first one is outermost.
+func Chain(m ...Middleware) Middleware {
+	return func(next Handler) Handler {
+		for i := len(m) - 1; i >= 0; i-- {
+			next = m[i](next)
+		}
+		return next
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChain(t *testing.T) {
+	var events []string
+	newMiddleware := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				events = append(events, "before "+name)
+				reply, err := next(ctx, req)
+				events = append(events, "after "+name)
+				return reply, err
+			}
+		}
+	}
+	h := Chain(newMiddleware("1"), newMiddleware("2"))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		events = append(events, "handler")
+		return req, nil
+	})
+
+	reply, err := h(context.Background(), "req")
+	assert.NoError(t, err)
+	assert.Equal(t, "req", reply)
+	assert.Equal(t, []string{"before 1", "before 2", "handler", "after 2", "after 1"}, events)
+}
@@ -0,0 +1,59 @@
+// Package recovery provides a middleware recovering from panics of the
+// handlers, for the HTTP routes and the gRPC unary and stream methods.
+package recovery
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/tkeel-io/kit/errors"
+	"github.com/tkeel-io/kit/log"
+	"github.com/tkeel-io/kit/middleware"
+)
+
+// HandlerFunc returns the error replied for the panic value p.
+type HandlerFunc func(ctx context.Context, req, p interface{}) error
+
+// Option is a recovery option.
+type Option func(o *options)
+
+type options struct {
+	handler HandlerFunc
+}
+
+// WithHandler sets the function returning the error of a panic,
+// errors.InternalError by default.
+func WithHandler(h HandlerFunc) Option {
+	return func(o *options) { o.handler = h }
+}
+
+// Recovery returns a middleware which logs the panic of next with its
+// stack and returns errors.InternalError instead. The HTTP server writes
+// the error in the result envelope and the gRPC server as the status.
+// http.ErrAbortHandler is panicked again, so that the handler can still
+// abort the response.
+func Recovery(opts ...Option) middleware.Middleware {
+	o := options{
+		handler: func(context.Context, interface{}, interface{}) error {
+			return errors.InternalError
+		},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
+			defer func() {
+				if p := recover(); p != nil {
+					if p == http.ErrAbortHandler {
+						panic(p)
+					}
+					log.Errorf("panic recovered: %v\n%s", p, debug.Stack())
+					reply, err = nil, o.handler(ctx, req, p)
+				}
+			}()
+			return next(ctx, req)
+		}
+	}
+}
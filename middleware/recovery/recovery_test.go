@@ -0,0 +1,113 @@
+package recovery
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tkeel-io/kit/errors"
+	transportGRPC "github.com/tkeel-io/kit/transport/grpc"
+	transportHTTP "github.com/tkeel-io/kit/transport/http"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestRecovery(t *testing.T) {
+	h := Recovery()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("boom")
+	})
+	reply, err := h(context.Background(), "req")
+	assert.Nil(t, reply)
+	assert.ErrorIs(t, err, errors.InternalError)
+
+	custom := errors.New(int(codes.Unavailable), "io.tkeel.UNAVAILABLE", "")
+	h = Recovery(WithHandler(func(ctx context.Context, req, p interface{}) error {
+		assert.Equal(t, "req", req)
+		assert.Equal(t, "boom", p)
+		return custom
+	}))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("boom")
+	})
+	_, err = h(context.Background(), "req")
+	assert.ErrorIs(t, err, custom)
+
+	h = Recovery()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic(http.ErrAbortHandler)
+	})
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() { _, _ = h(context.Background(), "req") })
+}
+
+func TestRecoveryHTTP(t *testing.T) {
+	s := transportHTTP.NewServer("127.0.0.1:0", transportHTTP.WithMiddleware(Recovery()))
+	ws := new(restful.WebService)
+	ws.Route(ws.GET("/panic").To(func(req *restful.Request, resp *restful.Response) {
+		panic("boom")
+	}))
+	ws.Route(ws.GET("/written").To(func(req *restful.Request, resp *restful.Response) {
+		_, _ = resp.Write([]byte("partial"))
+		panic("boom")
+	}))
+	s.Container.Add(ws)
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+
+	resp, err := http.Get(u.String() + "/panic")
+	require.NoError(t, err)
+	b, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.JSONEq(t, `{"code":"io.tkeel.INTERNAL_ERROR"}`, string(b))
+
+	// the response is not appended an envelope once started.
+	resp, err = http.Get(u.String() + "/written")
+	require.NoError(t, err)
+	b, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "partial", string(b))
+}
+
+type panicHealth struct {
+	*health.Server
+}
+
+func (panicHealth) Check(context.Context, *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	panic("boom")
+}
+
+func (panicHealth) Watch(*grpc_health_v1.HealthCheckRequest, grpc_health_v1.Health_WatchServer) error {
+	panic("boom")
+}
+
+func TestRecoveryGRPC(t *testing.T) {
+	s := transportGRPC.NewServer("127.0.0.1:0", transportGRPC.WithMiddleware(Recovery()))
+	grpc_health_v1.RegisterHealthServer(s.GetServe(), panicHealth{health.NewServer()})
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+
+	conn, err := grpc.Dial(u.Host, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, errors.INTERNAL_CODE, errors.FromError(err).Reason)
+
+	stream, err := grpc_health_v1.NewHealthClient(conn).Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, errors.INTERNAL_CODE, errors.FromError(err).Reason)
+}
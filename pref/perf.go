@@ -34,6 +34,7 @@ func Init(endpoints []string, handles ...*HandleFunc) {
 	}
 
 	for _, addr := range endpoints {
+		addr := addr
 		go func() {
 			listener, err := net.Listen("tcp", addr)
 			if err != nil {
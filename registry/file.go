@@ -0,0 +1,128 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	_ Registrar = (*File)(nil)
+	_ Discovery = (*File)(nil)
+)
+
+// File is a registry backed by a JSON file for local development,
+// the processes sharing the file discover each other. On the platforms
+// with flock, the updates hold an flock on the file path with the ".lock"
+// suffix, so that concurrent processes do not lose each other's entries.
+type File struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFile creates a registry stored in the file at path,
+// the file is created on first registration.
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+func (f *File) Register(ctx context.Context, service *ServiceInstance) error {
+	return f.update(func(services map[string][]*ServiceInstance) {
+		instances := removeInstance(services[service.Name], service.ID)
+		services[service.Name] = append(instances, service)
+	})
+}
+
+func (f *File) Deregister(ctx context.Context, service *ServiceInstance) error {
+	return f.update(func(services map[string][]*ServiceInstance) {
+		instances := removeInstance(services[service.Name], service.ID)
+		if len(instances) == 0 {
+			delete(services, service.Name)
+			return
+		}
+		services[service.Name] = instances
+	})
+}
+
+func (f *File) GetService(ctx context.Context, name string) ([]*ServiceInstance, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	services, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	return services[name], nil
+}
+
+func (f *File) update(fn func(services map[string][]*ServiceInstance)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	unlock, err := lockFile(f.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	services, err := f.load()
+	if err != nil {
+		return err
+	}
+	fn(services)
+	return f.save(services)
+}
+
+func (f *File) load() (map[string][]*ServiceInstance, error) {
+	services := make(map[string][]*ServiceInstance)
+	b, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return services, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error read registry file: %w", err)
+	}
+	if len(b) == 0 {
+		return services, nil
+	}
+	if err := json.Unmarshal(b, &services); err != nil {
+		return nil, fmt.Errorf("error unmarshal registry file: %w", err)
+	}
+	return services, nil
+}
+
+// save writes services to a temporary file and renames it,
+// so readers never see a partially written file.
+func (f *File) save(services map[string][]*ServiceInstance) error {
+	b, err := json.MarshalIndent(services, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshal registry file: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return fmt.Errorf("error create registry file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error write registry file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error write registry file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("error rename registry file: %w", err)
+	}
+	return nil
+}
+
+func removeInstance(instances []*ServiceInstance, id string) []*ServiceInstance {
+	ret := instances[:0]
+	for _, v := range instances {
+		if v.ID != id {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
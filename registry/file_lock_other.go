@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package registry
+
+// lockFile is a no-op on the platforms without flock, the File registry
+// is only safe for a single process there.
+func lockFile(path string) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,56 @@
+package registry
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	_ Registrar = (*Memory)(nil)
+	_ Discovery = (*Memory)(nil)
+)
+
+// Memory is an in-memory registry, it is safe for concurrent use.
+type Memory struct {
+	mu       sync.RWMutex
+	services map[string]map[string]*ServiceInstance
+}
+
+// NewMemory creates an empty in-memory registry.
+func NewMemory() *Memory {
+	return &Memory{
+		services: make(map[string]map[string]*ServiceInstance),
+	}
+}
+
+func (m *Memory) Register(ctx context.Context, service *ServiceInstance) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	instances, ok := m.services[service.Name]
+	if !ok {
+		instances = make(map[string]*ServiceInstance)
+		m.services[service.Name] = instances
+	}
+	instances[service.ID] = service
+	return nil
+}
+
+func (m *Memory) Deregister(ctx context.Context, service *ServiceInstance) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.services[service.Name], service.ID)
+	if len(m.services[service.Name]) == 0 {
+		delete(m.services, service.Name)
+	}
+	return nil
+}
+
+func (m *Memory) GetService(ctx context.Context, name string) ([]*ServiceInstance, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	instances := make([]*ServiceInstance, 0, len(m.services[name]))
+	for _, v := range m.services[name] {
+		instances = append(instances, v)
+	}
+	return instances, nil
+}
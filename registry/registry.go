@@ -0,0 +1,35 @@
+package registry
+
+import (
+	"context"
+)
+
+// Registrar registers service instances to a registry.
+type Registrar interface {
+	// Register registers the service instance.
+	Register(ctx context.Context, service *ServiceInstance) error
+	// Deregister deregisters the service instance.
+	Deregister(ctx context.Context, service *ServiceInstance) error
+}
+
+// Discovery looks up service instances from a registry.
+type Discovery interface {
+	// GetService returns the instances of the service with name.
+	GetService(ctx context.Context, name string) ([]*ServiceInstance, error)
+}
+
+// ServiceInstance is an instance of a service in the registry.
+type ServiceInstance struct {
+	// ID is the unique instance ID.
+	ID string `json:"id"`
+	// Name is the service name.
+	Name string `json:"name"`
+	// Version is the service version.
+	Version string `json:"version"`
+	// Metadata is the kv pair metadata associated with the instance.
+	Metadata map[string]string `json:"metadata"`
+	// Endpoints are the endpoint addresses of the instance, for example:
+	//   http://127.0.0.1:31234
+	//   grpc://127.0.0.1:31233
+	Endpoints []string `json:"endpoints"`
+}
@@ -0,0 +1,70 @@
+package registry
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRegistry(t *testing.T, r interface {
+	Registrar
+	Discovery
+}) {
+	ctx := context.Background()
+	s1 := &ServiceInstance{ID: "1", Name: "svc", Endpoints: []string{"http://127.0.0.1:1"}}
+	s2 := &ServiceInstance{ID: "2", Name: "svc", Endpoints: []string{"http://127.0.0.1:2"}}
+
+	require.NoError(t, r.Register(ctx, s1))
+	require.NoError(t, r.Register(ctx, s2))
+	instances, err := r.GetService(ctx, "svc")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []*ServiceInstance{s1, s2}, instances)
+
+	require.NoError(t, r.Deregister(ctx, s1))
+	instances, err = r.GetService(ctx, "svc")
+	require.NoError(t, err)
+	assert.Equal(t, []*ServiceInstance{s2}, instances)
+
+	require.NoError(t, r.Deregister(ctx, s2))
+	instances, err = r.GetService(ctx, "svc")
+	require.NoError(t, err)
+	assert.Empty(t, instances)
+}
+
+func TestMemory(t *testing.T) {
+	testRegistry(t, NewMemory())
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.json")
+	testRegistry(t, NewFile(path))
+
+	// another process sees the registered instances.
+	s := &ServiceInstance{ID: "1", Name: "svc"}
+	require.NoError(t, NewFile(path).Register(context.Background(), s))
+	instances, err := NewFile(path).GetService(context.Background(), "svc")
+	require.NoError(t, err)
+	assert.Equal(t, []*ServiceInstance{s}, instances)
+}
+
+func TestFileConcurrentProcesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.json")
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			// each File stands for a process with its own mutex.
+			assert.NoError(t, NewFile(path).Register(context.Background(), &ServiceInstance{ID: id, Name: "svc"}))
+		}(strconv.Itoa(i))
+	}
+	wg.Wait()
+	instances, err := NewFile(path).GetService(context.Background(), "svc")
+	require.NoError(t, err)
+	assert.Len(t, instances, 20)
+}
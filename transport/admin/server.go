@@ -0,0 +1,189 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tkeel-io/kit/internal/endpoint"
+	"github.com/tkeel-io/kit/log"
+	perf "github.com/tkeel-io/kit/pref"
+	"github.com/tkeel-io/kit/transport"
+	"github.com/tkeel-io/kit/transport/handoff"
+	"github.com/tkeel-io/kit/version"
+)
+
+const DefaultPort = ":31235"
+
+var (
+	_ transport.ErrorNotifier = (*Server)(nil)
+	_ transport.Readier       = (*Server)(nil)
+	_ transport.Endpointer    = (*Server)(nil)
+)
+
+// Check reports whether a part of the application works,
+// a non nil error marks the check as failed.
+type Check func(ctx context.Context) error
+
+// Server is an admin server serving:
+//
+//	/healthz       liveness, fails when a health check fails
+//	/readyz        readiness, fails until SetReady(true), which the App calls
+//	               once all the servers started, or when a readiness check fails
+//	/version       the build information of the version package
+//	/loglevel      GET or PUT {"level":"debug"} the level of the log package
+//	/debug/pprof/  the pprof handlers of perf.PerfHandles
+type Server struct {
+	Addr  string
+	srv   *http.Server
+	mux   *http.ServeMux
+	errCh chan error
+	lis   net.Listener
+	ready int32
+
+	mu              sync.RWMutex
+	healthChecks    map[string]Check
+	readinessChecks map[string]Check
+}
+
+func NewServer(addr string) *Server {
+	if addr == "" {
+		addr = DefaultPort
+	}
+	s := &Server{
+		Addr:            addr,
+		mux:             http.NewServeMux(),
+		errCh:           make(chan error, 1),
+		healthChecks:    make(map[string]Check),
+		readinessChecks: make(map[string]Check),
+	}
+	s.mux.HandleFunc("/healthz", s.healthz)
+	s.mux.HandleFunc("/readyz", s.readyz)
+	s.mux.HandleFunc("/version", s.version)
+	s.mux.Handle("/loglevel", log.AtomicLevel())
+	for _, handle := range perf.PerfHandles() {
+		s.mux.HandleFunc(strings.ReplaceAll("/debug/"+handle.Pattern, "//", "/"), handle.Handler)
+	}
+	s.srv = &http.Server{
+		Addr:    addr,
+		Handler: s.mux,
+	}
+	return s
+}
+
+// Handle registers an additional handler for pattern on the admin server.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// AddHealthCheck adds a check to /healthz.
+func (s *Server) AddHealthCheck(name string, check Check) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.healthChecks[name] = check
+}
+
+// AddReadinessCheck adds a check to /readyz.
+func (s *Server) AddReadinessCheck(name string, check Check) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.readinessChecks[name] = check
+}
+
+func (s *Server) Type() transport.Type {
+	return transport.TypeAdmin
+}
+
+func (s *Server) Err() <-chan error {
+	return s.errCh
+}
+
+// Endpoint returns the http URL of the listener, which is known after Start.
+func (s *Server) Endpoint() (*url.URL, error) {
+	return endpoint.URL("http", s.lis)
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	l, err := handoff.Listen("tcp", s.Addr)
+	if err != nil {
+		return fmt.Errorf("error listen addr: %w", err)
+	}
+	s.lis = l
+	log.Debugf("Admin Server listen: %s", s.Addr)
+	go func() {
+		if err := s.srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("error admin serve: %s", err)
+			s.errCh <- fmt.Errorf("error admin serve: %w", err)
+		}
+	}()
+	return nil
+}
+
+func (s *Server) Stop(ctx context.Context) error {
+	s.SetReady(false)
+	return s.srv.Shutdown(ctx)
+}
+
+// SetReady sets whether /readyz reports ready, the checks still apply.
+func (s *Server) SetReady(ready bool) {
+	var v int32
+	if ready {
+		v = 1
+	}
+	atomic.StoreInt32(&s.ready, v)
+}
+
+func (s *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	writeChecks(w, r, s.healthChecks)
+}
+
+func (s *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	if atomic.LoadInt32(&s.ready) == 0 {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	writeChecks(w, r, s.readinessChecks)
+}
+
+func (s *Server) version(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(map[string]string{
+		"version":    version.Version,
+		"git_commit": version.GitCommit,
+		"build_date": version.BuildDate,
+		"go_version": version.GoVersion,
+		"os_arch":    version.OsArch,
+	})
+	if err != nil {
+		log.Errorf("error write version: %s", err)
+	}
+}
+
+// writeChecks runs checks and writes 200 when all of them pass,
+// otherwise 503 with the failed checks.
+func writeChecks(w http.ResponseWriter, r *http.Request, checks map[string]Check) {
+	var failed []string
+	for name, check := range checks {
+		if err := check(r.Context()); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", name, err))
+		}
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		http.Error(w, strings.Join(failed, "\n"), http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = w.Write([]byte("ok"))
+}
@@ -0,0 +1,66 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tkeel-io/kit/log"
+	"github.com/tkeel-io/kit/version"
+	"go.uber.org/zap/zapcore"
+)
+
+func get(t *testing.T, url string) (int, string) {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(b)
+}
+
+func TestServer(t *testing.T) {
+	s := NewServer("127.0.0.1:0")
+	var dbErr error
+	s.AddReadinessCheck("db", func(ctx context.Context) error { return dbErr })
+	_, err := s.Endpoint()
+	assert.Error(t, err)
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+	addr := u.String()
+
+	code, _ := get(t, addr+"/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = get(t, addr+"/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	s.SetReady(true)
+	code, _ = get(t, addr+"/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	dbErr = errors.New("closed")
+	code, body := get(t, addr+"/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, body, "db: closed")
+
+	code, body = get(t, addr+"/version")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, version.Version)
+
+	code, _ = get(t, addr+"/debug/pprof/")
+	assert.Equal(t, http.StatusOK, code)
+
+	req, err := http.NewRequest(http.MethodPut, addr+"/loglevel", strings.NewReader(`{"level":"error"}`))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, zapcore.ErrorLevel, log.AtomicLevel().Level())
+	log.AtomicLevel().SetLevel(zapcore.DebugLevel)
+}
@@ -0,0 +1,127 @@
+package grpc
+
+import (
+	"context"
+	"sync"
+
+	"github.com/tkeel-io/kit/internal/ctxutil"
+	"github.com/tkeel-io/kit/log"
+	"github.com/tkeel-io/kit/middleware"
+	"github.com/tkeel-io/kit/transport"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// unaryServerInterceptor makes the values of the server base context and
+// the Transport available in unary handlers, and sends the reply header.
+func (s *Server) unaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, tr := s.newServerContext(ctx, info.FullMethod)
+		reply, err := handler(ctx, req)
+		if len(tr.replyHeader) > 0 {
+			if herr := grpc.SetHeader(ctx, metadata.MD(tr.replyHeader)); herr != nil {
+				log.Errorf("error set reply header: %s", herr)
+			}
+		}
+		return reply, err
+	}
+}
+
+// streamServerInterceptor makes the values of the server base context and
+// the Transport available in stream handlers, and sends the reply header
+// with the first message, or when the handler returns.
+func (s *Server) streamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, tr := s.newServerContext(ss.Context(), info.FullMethod)
+		ws := &wrappedStream{ServerStream: ss, ctx: ctx, tr: tr}
+		err := handler(srv, ws)
+		ws.setReplyHeader()
+		return err
+	}
+}
+
+// middlewareInterceptor calls the unary handler through the server middleware.
+func (s *Server) middlewareInterceptor() grpc.UnaryServerInterceptor {
+	chain := middleware.Chain(s.middleware...)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if len(s.middleware) == 0 {
+			return handler(ctx, req)
+		}
+		return chain(middleware.Handler(handler))(ctx, req)
+	}
+}
+
+// streamMiddlewareInterceptor calls the stream handler through the server
+// middleware, with the stream as the request and a nil reply.
+func (s *Server) streamMiddlewareInterceptor() grpc.StreamServerInterceptor {
+	chain := middleware.Chain(s.middleware...)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if len(s.middleware) == 0 {
+			return handler(srv, ss)
+		}
+		h := chain(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, handler(srv, &contextStream{ServerStream: req.(grpc.ServerStream), ctx: ctx})
+		})
+		_, err := h(ss.Context(), ss)
+		return err
+	}
+}
+
+func (s *Server) newServerContext(ctx context.Context, operation string) (context.Context, *Transport) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		md = metadata.MD{}
+	}
+	tr := &Transport{
+		endpoint:    s.endpoint,
+		operation:   operation,
+		reqHeader:   headerCarrier(md),
+		replyHeader: headerCarrier(metadata.MD{}),
+	}
+	ctx = ctxutil.WithValues(ctx, s.baseCtx)
+	return transport.NewServerContext(ctx, tr), tr
+}
+
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	tr   *Transport
+	once sync.Once
+}
+
+func (w *wrappedStream) Context() context.Context {
+	return w.ctx
+}
+
+func (w *wrappedStream) SendHeader(md metadata.MD) error {
+	w.setReplyHeader()
+	return w.ServerStream.SendHeader(md)
+}
+
+func (w *wrappedStream) SendMsg(m interface{}) error {
+	w.setReplyHeader()
+	return w.ServerStream.SendMsg(m)
+}
+
+// contextStream is a stream with the context given by the middleware.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (c *contextStream) Context() context.Context {
+	return c.ctx
+}
+
+// setReplyHeader sets the reply header of the Transport once,
+// before the header is sent.
+func (w *wrappedStream) setReplyHeader() {
+	w.once.Do(func() {
+		if len(w.tr.replyHeader) == 0 {
+			return
+		}
+		if err := w.ServerStream.SetHeader(metadata.MD(w.tr.replyHeader)); err != nil {
+			log.Errorf("error set reply header: %s", err)
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package grpc
+
+import (
+	"net"
+
+	"github.com/tkeel-io/kit/middleware"
+	"github.com/tkeel-io/kit/transport"
+)
+
+// ServerOption is a server option.
+type ServerOption func(s *Server)
+
+// WithListener makes the server serve on l instead of listening on its
+// address, the server closes l on Stop.
+func WithListener(l net.Listener) ServerOption {
+	return func(s *Server) {
+		s.lis = l
+		s.injected = true
+	}
+}
+
+// WithTLSConfig makes the server serve TLS with c,
+// the certificates are reloaded when the files change.
+func WithTLSConfig(c *transport.TLSConfig) ServerOption {
+	return func(s *Server) {
+		s.certs = transport.NewCertReloader(c)
+	}
+}
+
+// WithMiddleware installs m as a unary and a stream interceptor,
+// see middleware.Handler for its req and reply.
+func WithMiddleware(m ...middleware.Middleware) ServerOption {
+	return func(s *Server) {
+		s.middleware = append(s.middleware, m...)
+	}
+}
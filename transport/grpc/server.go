@@ -2,29 +2,64 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"net/url"
 
+	"github.com/tkeel-io/kit/internal/endpoint"
 	"github.com/tkeel-io/kit/log"
+	"github.com/tkeel-io/kit/middleware"
 	"github.com/tkeel-io/kit/transport"
+	"github.com/tkeel-io/kit/transport/handoff"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 const DefaultPort = ":31233"
 
+var (
+	_ transport.ErrorNotifier = (*Server)(nil)
+	_ transport.Endpointer    = (*Server)(nil)
+)
+
 type Server struct {
-	Addr string
-	srv  *grpc.Server
+	Addr  string
+	srv   *grpc.Server
+	errCh chan error
+	lis   net.Listener
+	// injected reports whether lis is given by WithListener.
+	injected bool
+	certs    *transport.CertReloader
+	// endpoint is the Endpoint of the Transport, set on Start.
+	endpoint   string
+	middleware []middleware.Middleware
+	baseCtx    context.Context
 }
 
-func NewServer(addr string) *Server {
+// NewServer creates a server for addr, which is a TCP address such as
+// ":31233" or a unix domain socket such as "unix:///var/run/tkeel.sock".
+func NewServer(addr string, opts ...ServerOption) *Server {
 	if addr == "" {
 		addr = DefaultPort
 	}
-	return &Server{
-		Addr: addr,
-		srv:  grpc.NewServer(),
+	s := &Server{
+		Addr:    addr,
+		errCh:   make(chan error, 1),
+		baseCtx: context.Background(),
+	}
+	for _, o := range opts {
+		o(s)
 	}
+	grpcOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(s.unaryServerInterceptor(), s.middlewareInterceptor()),
+		grpc.ChainStreamInterceptor(s.streamServerInterceptor(), s.streamMiddlewareInterceptor()),
+	}
+	if s.certs != nil {
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(s.certs.TLSConfig("h2"))))
+	}
+	s.srv = grpc.NewServer(grpcOpts...)
+	return s
 }
 
 func (s *Server) GetServe() *grpc.Server {
@@ -35,21 +70,81 @@ func (s *Server) Type() transport.Type {
 	return transport.TypeGRPC
 }
 
+func (s *Server) Err() <-chan error {
+	return s.errCh
+}
+
+// Endpoint returns the grpc URL of the listener, or the grpcs URL when
+// serving TLS, which is known after Start.
+func (s *Server) Endpoint() (*url.URL, error) {
+	if s.certs != nil {
+		return endpoint.URL("grpcs", s.lis)
+	}
+	return endpoint.URL("grpc", s.lis)
+}
+
+// Start listens on the server address and serves in background,
+// handler contexts carry the values of ctx and the Transport.
 func (s *Server) Start(ctx context.Context) error {
-	l, err := net.Listen("tcp", s.Addr)
+	s.baseCtx = ctx
+	if s.certs != nil {
+		if err := s.certs.Load(); err != nil {
+			return fmt.Errorf("error load tls config: %w", err)
+		}
+	}
+	l, err := s.listen()
 	if err != nil {
-		return fmt.Errorf("error listen addr: %w", err)
+		return err
+	}
+	if u, err := s.Endpoint(); err == nil {
+		s.endpoint = u.String()
 	}
 	log.Debugf("GRPC Server listen: %s", s.Addr)
 	go func() {
-		if err := s.srv.Serve(l); err != nil {
-			log.Errorf("error http serve: %s", err)
+		if err := s.srv.Serve(l); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			log.Errorf("error grpc serve: %s", err)
+			s.errCh <- fmt.Errorf("error grpc serve: %w", err)
 		}
 	}()
 	return nil
 }
 
+// SetEndpoint sets the Endpoint of the Transport when the server is served
+// by another one, such as mux.Server, instead of its own Start.
+func (s *Server) SetEndpoint(endpoint string) {
+	s.endpoint = endpoint
+}
+
+// listen returns the listener given by WithListener, or listens on the
+// server address. The socket file of a unix address is removed on Stop.
+func (s *Server) listen() (net.Listener, error) {
+	if s.lis != nil && s.injected {
+		return s.lis, nil
+	}
+	l, err := handoff.Listen(endpoint.ParseAddr(s.Addr))
+	if err != nil {
+		return nil, fmt.Errorf("error listen addr: %w", err)
+	}
+	s.lis = l
+	return l, nil
+}
+
+// Stop stops the server gracefully, pending RPCs are canceled once ctx is done.
 func (s *Server) Stop(ctx context.Context) error {
-	s.srv.Stop()
+	done := make(chan struct{})
+	go func() {
+		s.srv.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.srv.Stop()
+		<-done
+	}
+	// Serve may not have taken the listener yet.
+	if s.lis != nil {
+		_ = s.lis.Close()
+	}
 	return nil
 }
@@ -0,0 +1,189 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tkeel-io/kit/middleware"
+	"github.com/tkeel-io/kit/transport"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestServerStartBindError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	s := NewServer(l.Addr().String())
+	assert.Error(t, s.Start(context.Background()))
+}
+
+type ctxKey struct{}
+
+func TestServerBaseContext(t *testing.T) {
+	s := NewServer("127.0.0.1:0")
+	s.baseCtx = context.WithValue(context.Background(), ctxKey{}, "value")
+
+	_, err := s.unaryServerInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			assert.Equal(t, "value", ctx.Value(ctxKey{}))
+			return nil, nil
+		})
+	assert.NoError(t, err)
+}
+
+func TestServerEndpoint(t *testing.T) {
+	s := NewServer("127.0.0.1:0")
+	_, err := s.Endpoint()
+	assert.Error(t, err)
+
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+	assert.Equal(t, "grpc", u.Scheme)
+	assert.Equal(t, s.lis.Addr().String(), u.Host)
+}
+
+func TestServerUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grpc.sock")
+	s := NewServer("unix://" + path)
+	require.NoError(t, s.Start(context.Background()))
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+	assert.Equal(t, "unix://"+path, u.String())
+	assert.FileExists(t, path)
+
+	require.NoError(t, s.Stop(context.Background()))
+	assert.NoFileExists(t, path)
+}
+
+func TestServerWithListener(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := NewServer("", WithListener(l))
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+	assert.Equal(t, l.Addr().String(), u.Host)
+}
+
+func TestServerTransport(t *testing.T) {
+	s := NewServer("127.0.0.1:0")
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-test", "value"))
+	info := &grpc.UnaryServerInfo{FullMethod: "/helloworld.Greeter/SayHello"}
+
+	_, err := s.unaryServerInterceptor()(ctx, nil, info,
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			require.True(t, ok)
+			assert.Equal(t, transport.TypeGRPC, tr.Kind())
+			assert.Equal(t, "/helloworld.Greeter/SayHello", tr.Operation())
+			assert.Equal(t, "value", tr.RequestHeader().Get("x-test"))
+			return nil, nil
+		})
+	assert.NoError(t, err)
+}
+
+func TestServerMiddleware(t *testing.T) {
+	var events []string
+	m := func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			events = append(events, "middleware")
+			return next(ctx, req)
+		}
+	}
+	s := NewServer("127.0.0.1:0", WithMiddleware(m))
+	reply, err := s.middlewareInterceptor()(context.Background(), "req", &grpc.UnaryServerInfo{},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			events = append(events, "handler")
+			return req, nil
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, "req", reply)
+	assert.Equal(t, []string{"middleware", "handler"}, events)
+}
+
+type replyHeaderHealth struct {
+	grpc_health_v1.UnimplementedHealthServer
+}
+
+func (replyHeaderHealth) Watch(_ *grpc_health_v1.HealthCheckRequest, ss grpc_health_v1.Health_WatchServer) error {
+	tr, ok := transport.FromServerContext(ss.Context())
+	if !ok {
+		return errors.New("no transport")
+	}
+	tr.ReplyHeader().Set("x-reply", "reply")
+	if err := ss.Send(&grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}); err != nil {
+		return err
+	}
+	<-ss.Context().Done()
+	return nil
+}
+
+func TestServerStreamReplyHeader(t *testing.T) {
+	s := NewServer("127.0.0.1:0")
+	grpc_health_v1.RegisterHealthServer(s.GetServe(), replyHeaderHealth{})
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+
+	conn, err := grpc.Dial(u.Host, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := grpc_health_v1.NewHealthClient(conn).Watch(ctx, &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+	md, err := stream.Header()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"reply"}, md.Get("x-reply"))
+}
+
+type userHealth struct {
+	grpc_health_v1.UnimplementedHealthServer
+}
+
+func (userHealth) Watch(_ *grpc_health_v1.HealthCheckRequest, ss grpc_health_v1.Health_WatchServer) error {
+	if v, _ := ss.Context().Value(ctxKey{}).(string); v != "user" {
+		return errors.New("no user")
+	}
+	return ss.Send(&grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING})
+}
+
+func TestServerStreamMiddleware(t *testing.T) {
+	m := func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if _, ok := req.(grpc.ServerStream); !ok {
+				return nil, errors.New("no stream")
+			}
+			return next(context.WithValue(ctx, ctxKey{}, "user"), req)
+		}
+	}
+	s := NewServer("127.0.0.1:0", WithMiddleware(m))
+	grpc_health_v1.RegisterHealthServer(s.GetServe(), userHealth{})
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+
+	conn, err := grpc.Dial(u.Host, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+	stream, err := grpc_health_v1.NewHealthClient(conn).Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+	reply, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, reply.Status)
+}
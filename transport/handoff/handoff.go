@@ -0,0 +1,196 @@
+// Package handoff passes listening sockets from a process to its
+// replacement, so the servers can restart without dropping connections.
+//
+// The old process calls Restart, which starts the same executable with the
+// active listeners passed as extra files. The new process gets them back
+// from Listen and calls Ready once it is serving, which sends SIGTERM to the
+// old process to make it drain and exit.
+package handoff
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+const (
+	// envListeners holds the JSON encoded addresses of the inherited
+	// listeners, in the order of their file descriptors starting at 3.
+	envListeners = "KIT_HANDOFF_LISTENERS"
+	// envParent holds the pid of the process to signal in Ready.
+	envParent = "KIT_HANDOFF_PARENT"
+)
+
+type address struct {
+	Network string `json:"network"`
+	Addr    string `json:"addr"`
+}
+
+type filer interface {
+	File() (*os.File, error)
+}
+
+var (
+	once      sync.Once
+	inheritMu sync.Mutex
+	inherited map[address]net.Listener
+
+	activeMu sync.Mutex
+	active   = make(map[*listener]struct{})
+)
+
+type listener struct {
+	net.Listener
+	addr address
+}
+
+func (l *listener) Close() error {
+	activeMu.Lock()
+	delete(active, l)
+	activeMu.Unlock()
+	return l.Listener.Close()
+}
+
+// Listen returns the listener inherited from the old process for network
+// and addr if there is one, otherwise it announces on the local address.
+// The returned listener is handed off by Restart until it is closed.
+func Listen(network, addr string) (net.Listener, error) {
+	once.Do(func() {
+		// file descriptors 0, 1 and 2 are stdin, stdout and stderr.
+		inherited = inherit(os.Getenv(envListeners), 3)
+		os.Unsetenv(envListeners)
+	})
+
+	a := address{Network: network, Addr: addr}
+	inheritMu.Lock()
+	l, ok := inherited[a]
+	delete(inherited, a)
+	inheritMu.Unlock()
+	if !ok {
+		var err error
+		if l, err = net.Listen(network, addr); err != nil {
+			return nil, err
+		}
+	}
+
+	ret := &listener{Listener: l, addr: a}
+	activeMu.Lock()
+	active[ret] = struct{}{}
+	activeMu.Unlock()
+	return ret, nil
+}
+
+// inherit rebuilds the listeners described by env from the file
+// descriptors starting at fd.
+func inherit(env string, fd int) map[address]net.Listener {
+	listeners := make(map[address]net.Listener)
+	if env == "" {
+		return listeners
+	}
+	var addrs []address
+	if err := json.Unmarshal([]byte(env), &addrs); err != nil {
+		return listeners
+	}
+	for i, a := range addrs {
+		f := os.NewFile(uintptr(fd+i), a.Network+":"+a.Addr)
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			continue
+		}
+		// the new process owns the socket file from now on.
+		if ul, ok := l.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(true)
+		}
+		listeners[a] = l
+	}
+	return listeners
+}
+
+// Inherited reports whether the process was started by Restart.
+func Inherited() bool {
+	return os.Getenv(envParent) != ""
+}
+
+// Restart starts a new process of the current executable with the same
+// arguments and environment, handing off all active listeners to it.
+func Restart() (*os.Process, error) {
+	activeMu.Lock()
+	addrs := make([]address, 0, len(active))
+	files := make([]*os.File, 0, len(active))
+	for l := range active {
+		fl, ok := l.Listener.(filer)
+		if !ok {
+			continue
+		}
+		// the socket file must outlive the old process.
+		if ul, ok := l.Listener.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+		f, err := fl.File()
+		if err != nil {
+			activeMu.Unlock()
+			closeFiles(files)
+			return nil, fmt.Errorf("error get listener file: %w", err)
+		}
+		addrs = append(addrs, l.addr)
+		files = append(files, f)
+	}
+	activeMu.Unlock()
+	defer closeFiles(files)
+
+	b, err := json.Marshal(addrs)
+	if err != nil {
+		return nil, fmt.Errorf("error marshal listener addresses: %w", err)
+	}
+	path, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("error get executable: %w", err)
+	}
+	cmd := exec.Command(path, os.Args[1:]...)
+	cmd.Env = append(os.Environ(),
+		envListeners+"="+string(b),
+		envParent+"="+strconv.Itoa(os.Getpid()),
+	)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = files
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error start process: %w", err)
+	}
+	return cmd.Process, nil
+}
+
+// Ready tells the old process that the new one is serving, so the old
+// process stops. It does nothing when the process was not started by Restart.
+func Ready() error {
+	env := os.Getenv(envParent)
+	if env == "" {
+		return nil
+	}
+	os.Unsetenv(envParent)
+	pid, err := strconv.Atoi(env)
+	if err != nil {
+		return fmt.Errorf("error parse parent pid: %w", err)
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return fmt.Errorf("error find parent process: %w", err)
+	}
+	if err := p.Signal(syscall.SIGTERM); err != nil {
+		return fmt.Errorf("error signal parent process: %w", err)
+	}
+	return nil
+}
+
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		f.Close()
+	}
+}
@@ -0,0 +1,44 @@
+package handoff
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInherit(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	f, err := l.(*net.TCPListener).File()
+	require.NoError(t, err)
+
+	a := address{Network: "tcp", Addr: ":31234"}
+	env, err := json.Marshal([]address{a})
+	require.NoError(t, err)
+	listeners := inherit(string(env), int(f.Fd()))
+	require.Len(t, listeners, 1)
+	defer listeners[a].Close()
+	assert.Equal(t, l.Addr().String(), listeners[a].Addr().String())
+
+	assert.Empty(t, inherit("", 3))
+	assert.Empty(t, inherit("invalid", 3))
+}
+
+func TestListen(t *testing.T) {
+	l, err := Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	activeMu.Lock()
+	assert.Len(t, active, 1)
+	activeMu.Unlock()
+
+	require.NoError(t, l.Close())
+	activeMu.Lock()
+	assert.Empty(t, active)
+	activeMu.Unlock()
+	assert.False(t, Inherited())
+	assert.NoError(t, Ready())
+}
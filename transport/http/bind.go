@@ -0,0 +1,273 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/emicklei/go-restful"
+	"github.com/tkeel-io/kit/encoding"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Sources of the bound values.
+const (
+	BindSourcePath  = "path"
+	BindSourceQuery = "query"
+	BindSourceBody  = "body"
+)
+
+// BindError is returned by Bind when a value of the request can not be
+// bound, Field is empty when the error is not about a single field.
+type BindError struct {
+	Source string
+	Field  string
+	Err    error
+}
+
+func (e *BindError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("error bind %s: %s", e.Source, e.Err)
+	}
+	return fmt.Sprintf("error bind %s field %q: %s", e.Source, e.Field, e.Err)
+}
+
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// BindOption is a Bind option.
+type BindOption func(o *bindOptions)
+
+type bindOptions struct {
+	bodyField string
+}
+
+// WithBodyField binds the body to the field of the request message, like
+// the body of a google.api.http rule. The default "*" binds the body to the
+// whole message, and "" ignores the body.
+func WithBodyField(field string) BindOption {
+	return func(o *bindOptions) { o.bodyField = field }
+}
+
+// Bind binds the query, the body and the path parameters of req to in.
+// A field set by several sources takes the value of the path first, then
+// the body, then the query, including the zero values a source gives
+// explicitly. The fields given by a body which is not JSON or YAML are the
+// populated ones. The errors are *BindError.
+//
+// Values are merged field by field only if in is a proto.Message, other
+// types are decoded from the query, the body and the path in sequence.
+func Bind(req *restful.Request, in interface{}, opts ...BindOption) error {
+	o := bindOptions{bodyField: "*"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	m, ok := in.(proto.Message)
+	if !ok {
+		return bindValue(req, in, &o)
+	}
+
+	query := m.ProtoReflect().New().Interface()
+	queryValues := req.Request.URL.Query()
+	if err := bindValues(BindSourceQuery, query, queryValues); err != nil {
+		return err
+	}
+	body := m.ProtoReflect().New().Interface()
+	bodyKeys, err := bindBody(req, body, o.bodyField)
+	if err != nil {
+		return err
+	}
+	path := m.ProtoReflect().New().Interface()
+	pathValues := make(url.Values, len(req.PathParameters()))
+	for k, v := range req.PathParameters() {
+		pathValues.Set(k, v)
+	}
+	if err := bindValues(BindSourcePath, path, pathValues); err != nil {
+		return err
+	}
+
+	dst := m.ProtoReflect()
+	merge(dst, query.ProtoReflect(), valueKeys(queryValues))
+	if bodyKeys != nil {
+		merge(dst, body.ProtoReflect(), bodyKeys)
+	} else {
+		override(dst, body.ProtoReflect())
+	}
+	merge(dst, path.ProtoReflect(), valueKeys(pathValues))
+	return nil
+}
+
+// bindValues decodes values into m key by key, so that the error names the field.
+func bindValues(source string, m proto.Message, values url.Values) error {
+	for k, v := range values {
+		if err := encoding.MapProto(m, map[string][]string{k: v}); err != nil {
+			return &BindError{Source: source, Field: k, Err: err}
+		}
+	}
+	return nil
+}
+
+// bindBody reads the body into m, or into its field named field. It
+// returns the fields given by the body, nil if they are not known.
+func bindBody(req *restful.Request, m proto.Message, field string) (fieldSet, error) {
+	if field == "" || req.Request.ContentLength == 0 {
+		return fieldSet{}, nil
+	}
+	data, err := io.ReadAll(req.Request.Body)
+	if err != nil {
+		return nil, &BindError{Source: BindSourceBody, Err: err}
+	}
+	if len(data) == 0 {
+		return fieldSet{}, nil
+	}
+	req.Request.Body = io.NopCloser(bytes.NewReader(data))
+	keys := documentKeys(req.HeaderParameter("Content-Type"), data)
+	if field == "*" {
+		if err := req.ReadEntity(m); err != nil {
+			return nil, &BindError{Source: BindSourceBody, Err: err}
+		}
+		return keys, nil
+	}
+
+	rm := m.ProtoReflect()
+	fields := rm.Descriptor().Fields()
+	fd := fields.ByName(protoreflect.Name(field))
+	if fd == nil {
+		fd = fields.ByJSONName(field)
+	}
+	if fd == nil || fd.Message() == nil || fd.IsList() || fd.IsMap() {
+		return nil, &BindError{Source: BindSourceBody, Field: field, Err: fmt.Errorf("not a message field of %s", rm.Descriptor().FullName())}
+	}
+	v := rm.NewField(fd)
+	if err := req.ReadEntity(v.Message().Interface()); err != nil {
+		return nil, &BindError{Source: BindSourceBody, Field: field, Err: err}
+	}
+	rm.Set(fd, v)
+	return fieldSet{string(fd.Name()): keys}, nil
+}
+
+// fieldSet is the tree of the field names given by a source,
+// a nil subtree stands for the whole field.
+type fieldSet map[string]fieldSet
+
+func (s fieldSet) add(path []string) {
+	sub, ok := s[path[0]]
+	if ok && sub == nil {
+		return
+	}
+	if len(path) == 1 {
+		s[path[0]] = nil
+		return
+	}
+	if sub == nil {
+		sub = fieldSet{}
+		s[path[0]] = sub
+	}
+	sub.add(path[1:])
+}
+
+// valueKeys returns the fields of the query or path values,
+// whose keys are field paths such as "source_context.file_name".
+func valueKeys(values url.Values) fieldSet {
+	s := make(fieldSet, len(values))
+	for k := range values {
+		s.add(strings.Split(k, "."))
+	}
+	return s
+}
+
+// documentKeys returns the fields of a JSON or YAML body, or nil for the
+// other content types.
+func documentKeys(contentType string, data []byte) fieldSet {
+	c, ok := encoding.CodecFor(contentType)
+	if !ok {
+		return nil
+	}
+	switch c.(type) {
+	case encoding.JSONCodec, encoding.YAMLCodec:
+	default:
+		return nil
+	}
+	var doc map[string]interface{}
+	if err := c.Unmarshal(data, &doc); err != nil {
+		return nil
+	}
+	return objectKeys(doc)
+}
+
+func objectKeys(obj map[string]interface{}) fieldSet {
+	s := make(fieldSet, len(obj))
+	for k, v := range obj {
+		if sub, ok := v.(map[string]interface{}); ok && len(sub) > 0 {
+			s[k] = objectKeys(sub)
+		} else {
+			s[k] = nil
+		}
+	}
+	return s
+}
+
+// merge sets the fields of keys from src to dst, zero values included, the
+// fields of singular messages are merged recursively except for the
+// well-known types.
+func merge(dst, src protoreflect.Message, keys fieldSet) {
+	fields := dst.Descriptor().Fields()
+	for k, sub := range keys {
+		fd := fields.ByName(protoreflect.Name(k))
+		if fd == nil {
+			fd = fields.ByJSONName(k)
+		}
+		if fd == nil {
+			continue
+		}
+		if sub != nil && mergeable(fd) {
+			merge(dst.Mutable(fd).Message(), src.Get(fd).Message(), sub)
+			continue
+		}
+		if src.Has(fd) {
+			dst.Set(fd, src.Get(fd))
+		} else {
+			dst.Clear(fd)
+		}
+	}
+}
+
+// override sets the populated fields of src to dst, the fields of singular
+// messages are merged recursively except for the well-known types.
+func override(dst, src protoreflect.Message) {
+	src.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if mergeable(fd) && dst.Has(fd) {
+			override(dst.Mutable(fd).Message(), v.Message())
+			return true
+		}
+		dst.Set(fd, v)
+		return true
+	})
+}
+
+// mergeable reports whether fd is a singular message field merged field by
+// field, the well-known types are set as a whole.
+func mergeable(fd protoreflect.FieldDescriptor) bool {
+	return fd.Message() != nil && !fd.IsList() && !fd.IsMap() &&
+		fd.Message().FullName().Parent() != "google.protobuf"
+}
+
+// bindValue decodes the query, the body and the path into v in sequence.
+func bindValue(req *restful.Request, v interface{}, o *bindOptions) error {
+	if err := GetQuery(req, v); err != nil {
+		return &BindError{Source: BindSourceQuery, Err: err}
+	}
+	if o.bodyField != "" {
+		if err := GetBody(req, v); err != nil {
+			return &BindError{Source: BindSourceBody, Err: err}
+		}
+	}
+	if err := GetPathValue(req, v); err != nil {
+		return &BindError{Source: BindSourcePath, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/apipb"
+	"google.golang.org/protobuf/types/known/sourcecontextpb"
+)
+
+func TestBind(t *testing.T) {
+	s := NewServer("127.0.0.1:0")
+	results := make(chan interface{}, 1)
+	bind := func(opts ...BindOption) restful.RouteFunction {
+		return func(req *restful.Request, resp *restful.Response) {
+			in := new(apipb.Api)
+			if err := Bind(req, in, opts...); err != nil {
+				results <- err
+				return
+			}
+			results <- in
+		}
+	}
+	ws := new(restful.WebService)
+	ws.Route(ws.POST("/apis/{name}").To(bind()))
+	ws.Route(ws.POST("/apis/{name}/source").To(bind(WithBodyField("source_context"))))
+	s.Container.Add(ws)
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+
+	post := func(path, body string) interface{} {
+		req, err := http.NewRequest(http.MethodPost, u.String()+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return <-results
+	}
+
+	got := post("/apis/path?name=query&version=query&source_context.file_name=query.proto", `{"version":"body"}`)
+	assert.True(t, proto.Equal(&apipb.Api{
+		Name:          "path",
+		Version:       "body",
+		SourceContext: &sourcecontextpb.SourceContext{FileName: "query.proto"},
+	}, got.(proto.Message)), got)
+
+	got = post("/apis/path/source?version=query&source_context.file_name=query.proto", `{"file_name":"body.proto"}`)
+	assert.True(t, proto.Equal(&apipb.Api{
+		Name:          "path",
+		Version:       "query",
+		SourceContext: &sourcecontextpb.SourceContext{FileName: "body.proto"},
+	}, got.(proto.Message)), got)
+
+	// the zero values given by the body override the query.
+	got = post("/apis/path?syntax=SYNTAX_PROTO3&version=query&source_context.file_name=query.proto",
+		`{"syntax":"SYNTAX_PROTO2","version":"","sourceContext":{"fileName":""}}`)
+	assert.True(t, proto.Equal(&apipb.Api{
+		Name:          "path",
+		SourceContext: &sourcecontextpb.SourceContext{},
+	}, got.(proto.Message)), got)
+
+	got = post("/apis/path?syntax=bad", "")
+	var be *BindError
+	require.True(t, errors.As(got.(error), &be))
+	assert.Equal(t, BindSourceQuery, be.Source)
+	assert.Equal(t, "syntax", be.Field)
+
+	got = post("/apis/path", "{")
+	require.True(t, errors.As(got.(error), &be))
+	assert.Equal(t, BindSourceBody, be.Source)
+}
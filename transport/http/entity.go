@@ -0,0 +1,82 @@
+package http
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/emicklei/go-restful"
+	"github.com/tkeel-io/kit/encoding"
+)
+
+// ContentTypes are the content types of the entity accessors registered by
+// NewServer, JSON first. Routes negotiate them only if they declare them:
+//
+//	ws.Consumes(ContentTypes...).Produces(ContentTypes...)
+var ContentTypes = []string{
+	encoding.ContentTypeJSON,
+	encoding.ContentTypeProtobuf,
+	encoding.ContentTypeYAML,
+	encoding.ContentTypeForm,
+}
+
+var registerOnce sync.Once
+
+// registerEntityAccessors registers the entity accessors of the encoding
+// codecs, so that the request and the response entities are chosen by
+// Content-Type and Accept. JSON is the default response entity.
+func registerEntityAccessors() {
+	registerOnce.Do(func() {
+		for _, contentType := range []string{
+			encoding.ContentTypeJSON,
+			encoding.ContentTypeProtobuf,
+			"application/protobuf",
+			encoding.ContentTypeYAML,
+			"application/x-yaml",
+			"text/yaml",
+		} {
+			if c, ok := encoding.CodecFor(contentType); ok {
+				restful.RegisterEntityAccessor(contentType, NewEntityAccessor(contentType, c))
+			}
+		}
+		restful.RegisterEntityAccessor(encoding.ContentTypeForm, FormEntityReadWriter{})
+		restful.DefaultResponseContentType(restful.MIME_JSON)
+	})
+}
+
+// NewEntityAccessor returns a restful.EntityReaderWriter of contentType with c.
+func NewEntityAccessor(contentType string, c encoding.Codec) restful.EntityReaderWriter {
+	return entityAccessor{contentType: contentType, codec: c}
+}
+
+type entityAccessor struct {
+	contentType string
+	codec       encoding.Codec
+}
+
+func (a entityAccessor) Read(req *restful.Request, v interface{}) error {
+	b, err := readBody(req)
+	if err != nil {
+		return err
+	}
+	if err := a.codec.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("error unmarshal %s: %w", a.contentType, err)
+	}
+	return nil
+}
+
+func (a entityAccessor) Write(resp *restful.Response, status int, v interface{}) error {
+	if v == nil {
+		resp.WriteHeader(status)
+		return nil
+	}
+	b, err := a.codec.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("error marshal %s: %w", a.contentType, err)
+	}
+	resp.Header().Set(restful.HEADER_ContentType, a.contentType)
+	resp.WriteHeader(status)
+	if _, err := resp.Write(b); err != nil {
+		return fmt.Errorf("error write response writer: %w", err)
+	}
+	return nil
+}
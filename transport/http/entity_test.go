@@ -0,0 +1,69 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tkeel-io/kit/encoding"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/apipb"
+	"google.golang.org/protobuf/types/known/typepb"
+)
+
+func TestEntityNegotiation(t *testing.T) {
+	s := NewServer("127.0.0.1:0")
+	ws := new(restful.WebService).Consumes(ContentTypes...).Produces(ContentTypes...)
+	ws.Route(ws.POST("/apis").To(func(req *restful.Request, resp *restful.Response) {
+		in := new(apipb.Api)
+		if err := req.ReadEntity(in); err != nil {
+			_ = resp.WriteError(http.StatusBadRequest, err)
+			return
+		}
+		_ = resp.WriteEntity(in)
+	}))
+	s.Container.Add(ws)
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+
+	want := &apipb.Api{Name: "api", Syntax: typepb.Syntax_SYNTAX_PROTO3}
+	tests := []struct {
+		contentType string
+		accept      string
+		wantType    string
+	}{
+		{encoding.ContentTypeJSON, "", encoding.ContentTypeJSON},
+		{encoding.ContentTypeYAML, "*/*", encoding.ContentTypeJSON},
+		{encoding.ContentTypeProtobuf, encoding.ContentTypeYAML, encoding.ContentTypeYAML},
+		{encoding.ContentTypeJSON + "; charset=utf-8", encoding.ContentTypeProtobuf, encoding.ContentTypeProtobuf},
+	}
+	for _, tt := range tests {
+		c, ok := encoding.CodecFor(tt.contentType)
+		require.True(t, ok)
+		body, err := c.Marshal(want)
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodPost, u.String()+"/apis", strings.NewReader(string(body)))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", tt.contentType)
+		req.Header.Set("Accept", tt.accept)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		b, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+		assert.Equal(t, tt.wantType, resp.Header.Get("Content-Type"))
+
+		c, _ = encoding.CodecFor(tt.wantType)
+		got := new(apipb.Api)
+		require.NoError(t, c.Unmarshal(b, got))
+		assert.True(t, proto.Equal(want, got), tt.contentType)
+	}
+}
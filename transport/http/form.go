@@ -26,6 +26,7 @@ func (ferw FormEntityReadWriter) Write(resp *restful.Response,
 	if err != nil {
 		return fmt.Errorf("error encoding marshal: %w", err)
 	}
+	resp.Header().Set(restful.HEADER_ContentType, encoding.ContentTypeForm)
 	resp.ResponseWriter.WriteHeader(status)
 	_, err = resp.ResponseWriter.Write(b)
 	if err != nil {
@@ -3,12 +3,28 @@ package http
 import (
 	"context"
 	"net/http"
+	"net/url"
+
+	"github.com/emicklei/go-restful"
 )
 
-var contextHTTPHeaderKey = struct{}{}
+type headerKey struct{}
+
+type requestInfoKey struct{}
+
+// RequestInfo is the path and query metadata of an HTTP request.
+type RequestInfo struct {
+	Method string
+	// Path is the request path, such as "/users/1".
+	Path string
+	// Operation is the route path, such as "/users/{id}".
+	Operation  string
+	PathParams map[string]string
+	Query      url.Values
+}
 
 func HeaderFromContext(ctx context.Context) http.Header {
-	h := ctx.Value(contextHTTPHeaderKey)
+	h := ctx.Value(headerKey{})
 	header, ok := h.(http.Header)
 	if !ok {
 		return nil
@@ -17,5 +33,31 @@ func HeaderFromContext(ctx context.Context) http.Header {
 }
 
 func ContextWithHeader(ctx context.Context, h http.Header) context.Context {
-	return context.WithValue(ctx, contextHTTPHeaderKey, h)
+	return context.WithValue(ctx, headerKey{}, h)
+}
+
+// RequestInfoFromContext returns the RequestInfo stored in ctx.
+func RequestInfoFromContext(ctx context.Context) (*RequestInfo, bool) {
+	info, ok := ctx.Value(requestInfoKey{}).(*RequestInfo)
+	return info, ok
+}
+
+// ContextWithRequestInfo returns a new context carrying info.
+func ContextWithRequestInfo(ctx context.Context, info *RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// headerFilter stores the request headers and the RequestInfo in the
+// request context, before the server middleware runs.
+func headerFilter(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+	ctx := ContextWithHeader(req.Request.Context(), req.Request.Header)
+	ctx = ContextWithRequestInfo(ctx, &RequestInfo{
+		Method:     req.Request.Method,
+		Path:       req.Request.URL.Path,
+		Operation:  req.SelectedRoutePath(),
+		PathParams: req.PathParameters(),
+		Query:      req.Request.URL.Query(),
+	})
+	req.Request = req.Request.WithContext(ctx)
+	chain.ProcessFilter(req, resp)
 }
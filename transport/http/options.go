@@ -0,0 +1,94 @@
+package http
+
+import (
+	"net"
+	"time"
+
+	"github.com/tkeel-io/kit/middleware"
+	"github.com/tkeel-io/kit/transport"
+)
+
+// ServerOption is a server option.
+type ServerOption func(s *Server)
+
+// WithListener makes the server serve on l instead of listening on its
+// address, the server closes l on Stop.
+func WithListener(l net.Listener) ServerOption {
+	return func(s *Server) {
+		s.lis = l
+		s.injected = true
+	}
+}
+
+// WithTLSConfig makes the server serve HTTPS with c,
+// the certificates are reloaded when the files change.
+func WithTLSConfig(c *transport.TLSConfig) ServerOption {
+	return func(s *Server) {
+		s.certs = transport.NewCertReloader(c)
+	}
+}
+
+// WithMiddleware installs m as a container filter, which runs for every
+// route of the server, see middleware.Handler for its req and reply.
+func WithMiddleware(m ...middleware.Middleware) ServerOption {
+	return func(s *Server) {
+		s.middleware = append(s.middleware, m...)
+	}
+}
+
+// WithReadHeaderTimeout sets the time to read the request headers,
+// DefaultReadHeaderTimeout by default, zero means no timeout.
+func WithReadHeaderTimeout(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.srv.ReadHeaderTimeout = d
+	}
+}
+
+// WithReadTimeout sets the time to read an entire request including the
+// body, DefaultReadTimeout by default, zero means no timeout.
+func WithReadTimeout(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.srv.ReadTimeout = d
+	}
+}
+
+// WithWriteTimeout sets the time to write a response, no timeout by default.
+// The timeout also ends event streams living longer than d.
+func WithWriteTimeout(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.srv.WriteTimeout = d
+	}
+}
+
+// WithIdleTimeout sets the time to wait for the next request on a
+// keep-alive connection, DefaultIdleTimeout by default.
+func WithIdleTimeout(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.srv.IdleTimeout = d
+	}
+}
+
+// WithMaxHeaderBytes sets the max size of the request headers,
+// DefaultMaxHeaderBytes by default.
+func WithMaxHeaderBytes(n int) ServerOption {
+	return func(s *Server) {
+		s.srv.MaxHeaderBytes = n
+	}
+}
+
+// WithMaxBodyBytes sets the max size of the request bodies,
+// DefaultMaxBodyBytes by default, zero means no limit. Reading a larger
+// body fails, MaxBodyBytes overrides the limit for a route.
+func WithMaxBodyBytes(n int64) ServerOption {
+	return func(s *Server) {
+		s.maxBodyBytes = n
+	}
+}
+
+// WithMaxConns limits the number of concurrent connections, the listener
+// stops accepting until a connection is closed. No limit by default.
+func WithMaxConns(n int) ServerOption {
+	return func(s *Server) {
+		s.maxConns = n
+	}
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"fmt"
+
+	"github.com/emicklei/go-restful"
+	"github.com/tkeel-io/kit/errors"
+	"github.com/tkeel-io/kit/log"
+	"github.com/tkeel-io/kit/result"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// WriteResult writes the result of a handler as a result.Http envelope in
+// the negotiated entity. On success the code is errors.SUCCESS_CODE and
+// out is the data. On failure the code and the message are the reason and
+// the message of the errors.TError, whose code decides the HTTP status.
+// An error without a reason is logged and replied as errors.InternalError,
+// so that its message is not exposed to the client. The message of
+// errors.NewRedirect is also set as the Location header.
+//
+//	func (h *Handler) Get(req *restful.Request, resp *restful.Response) {
+//		out, err := h.svc.Get(req.Request.Context(), in)
+//		WriteResult(resp, out, err)
+//	}
+func WriteResult(resp *restful.Response, out proto.Message, err error) {
+	status, envelope, err := newResult(out, err)
+	if err != nil {
+		log.Errorf("error build result: %s", err)
+		status, envelope, _ = newResult(nil, errors.InternalError)
+	}
+	if envelope.Code == errors.REDIRECT_CODE {
+		resp.Header().Set("Location", envelope.Msg)
+	}
+	if werr := resp.WriteHeaderAndEntity(status, envelope); werr != nil {
+		log.Errorf("error write result: %s", werr)
+	}
+}
+
+// newResult returns the HTTP status and the envelope of out and err,
+// or an error if out can not be packed.
+func newResult(out proto.Message, err error) (int, *result.Http, error) {
+	if err != nil {
+		te := errors.FromError(err)
+		if te.Reason == errors.UnknownReason {
+			log.Errorf("error handle request: %s", err)
+			te = errors.InternalError
+		}
+		return te.ToHTTPStatusCode(), &result.Http{Code: te.Reason, Msg: te.Message}, nil
+	}
+	envelope := &result.Http{Code: errors.SUCCESS_CODE}
+	if out != nil {
+		data, err := anypb.New(out)
+		if err != nil {
+			return 0, nil, fmt.Errorf("error pack result data: %w", err)
+		}
+		envelope.Data = data
+	}
+	return errors.Success.ToHTTPStatusCode(), envelope, nil
+}
@@ -0,0 +1,75 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tkeel-io/kit/errors"
+	"github.com/tkeel-io/kit/result"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestWriteResult(t *testing.T) {
+	s := NewServer("127.0.0.1:0")
+	ws := new(restful.WebService)
+	ws.Route(ws.GET("/ok").To(func(req *restful.Request, resp *restful.Response) {
+		WriteResult(resp, wrapperspb.String("data"), nil)
+	}))
+	ws.Route(ws.GET("/notfound").To(func(req *restful.Request, resp *restful.Response) {
+		WriteResult(resp, nil, errors.New(int(codes.NotFound), "io.tkeel.NOT_FOUND", "not found"))
+	}))
+	ws.Route(ws.GET("/unknown").To(func(req *restful.Request, resp *restful.Response) {
+		WriteResult(resp, nil, fmt.Errorf("boom"))
+	}))
+	ws.Route(ws.GET("/redirect").To(func(req *restful.Request, resp *restful.Response) {
+		WriteResult(resp, nil, errors.NewRedirect("/ok"))
+	}))
+	s.Container.Add(ws)
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	get := func(path string) (*http.Response, *result.Http) {
+		resp, err := client.Get(u.String() + path)
+		require.NoError(t, err)
+		b, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+		out := new(result.Http)
+		require.NoError(t, protojson.Unmarshal(b, out), string(b))
+		return resp, out
+	}
+
+	resp, out := get("/ok")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, errors.SUCCESS_CODE, out.Code)
+	data := new(wrapperspb.StringValue)
+	require.NoError(t, out.Data.UnmarshalTo(data))
+	assert.True(t, proto.Equal(wrapperspb.String("data"), data))
+
+	resp, out = get("/notfound")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.True(t, proto.Equal(&result.Http{Code: "io.tkeel.NOT_FOUND", Msg: "not found"}, out), out)
+
+	resp, out = get("/unknown")
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.True(t, proto.Equal(&result.Http{Code: errors.INTERNAL_CODE}, out), out)
+
+	resp, out = get("/redirect")
+	assert.Equal(t, http.StatusMovedPermanently, resp.StatusCode)
+	assert.Equal(t, "/ok", resp.Header.Get("Location"))
+	assert.Equal(t, errors.REDIRECT_CODE, out.Code)
+}
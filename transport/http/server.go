@@ -2,11 +2,26 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/emicklei/go-restful"
+	"github.com/gorilla/websocket"
+	"github.com/tkeel-io/kit/internal/ctxutil"
+	"github.com/tkeel-io/kit/internal/endpoint"
+	"github.com/tkeel-io/kit/internal/inflight"
 	"github.com/tkeel-io/kit/log"
+	"github.com/tkeel-io/kit/middleware"
 	"github.com/tkeel-io/kit/transport"
+	"github.com/tkeel-io/kit/transport/handoff"
+	"golang.org/x/net/netutil"
 )
 
 const (
@@ -15,48 +30,185 @@ const (
 
 const DefaultPort = ":31234"
 
+// Production defaults of the server limits. There is no default write
+// timeout, which would end the event streams, see WithWriteTimeout.
+const (
+	DefaultReadHeaderTimeout = 10 * time.Second
+	DefaultReadTimeout       = 60 * time.Second
+	DefaultIdleTimeout       = 120 * time.Second
+	DefaultMaxHeaderBytes    = 1 << 20
+	DefaultMaxBodyBytes      = 32 << 20
+)
+
+var (
+	_ transport.ErrorNotifier = (*Server)(nil)
+	_ transport.Endpointer    = (*Server)(nil)
+)
+
 type Server struct {
-	Addr string
-	srv  *http.Server
+	Addr  string
+	srv   *http.Server
+	errCh chan error
+	lis   net.Listener
+	// injected reports whether lis is given by WithListener.
+	injected bool
+	certs    *transport.CertReloader
+	// endpoint is the Endpoint of the Transport, set on Start.
+	endpoint   string
+	middleware []middleware.Middleware
+	// handler calls the rest of the filter chain through the middleware.
+	handler middleware.Handler
+	// maxBodyBytes limits the request bodies, no limit if not positive.
+	maxBodyBytes int64
+	// maxConns limits the concurrent connections, no limit if not positive.
+	maxConns int
+	// done is closed on Stop to end the long-lived streams.
+	done     chan struct{}
+	stopOnce sync.Once
+	// sockets counts the running websocket handlers, their connections
+	// are hijacked and not waited by the shutdown.
+	sockets inflight.Counter
 
 	Container *restful.Container
 }
 
-func NewServer(addr string) *Server {
+// NewServer creates a server for addr, which is a TCP address such as
+// ":31234" or a unix domain socket such as "unix:///var/run/tkeel.sock".
+func NewServer(addr string, opts ...ServerOption) *Server {
 	if addr == "" {
 		addr = DefaultPort
 	}
 	c := restful.NewContainer()
-	restful.RegisterEntityAccessor("application/x-www-form-urlencoded", FormEntityReadWriter{})
+	registerEntityAccessors()
 	c.EnableContentEncoding(true)
 	restful.TraceLogger(&httpLog{})
 	restful.SetLogger(&httpLog{})
-	return &Server{
-		Addr:      addr,
-		Container: c,
-		srv: &http.Server{
-			Addr:    addr,
-			Handler: c,
-		},
+	s := &Server{
+		Addr:         addr,
+		Container:    c,
+		errCh:        make(chan error, 1),
+		done:         make(chan struct{}),
+		maxBodyBytes: DefaultMaxBodyBytes,
 	}
+	s.srv = &http.Server{
+		Addr:              addr,
+		Handler:           s,
+		ReadHeaderTimeout: DefaultReadHeaderTimeout,
+		ReadTimeout:       DefaultReadTimeout,
+		IdleTimeout:       DefaultIdleTimeout,
+		MaxHeaderBytes:    DefaultMaxHeaderBytes,
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	c.Filter(s.transportFilter)
+	c.Filter(headerFilter)
+	if len(s.middleware) > 0 {
+		s.handler = middleware.Chain(s.middleware...)(processFilterChain)
+		c.Filter(s.middlewareFilter)
+	}
+	return s
+}
+
+// ServeHTTP serves r with the container. Event stream and websocket
+// requests are not compressed, so that they can be flushed and hijacked.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") || websocket.IsWebSocketUpgrade(r) {
+		r.Header.Del("Accept-Encoding")
+	}
+	s.Container.ServeHTTP(w, r)
 }
 
 func (s *Server) Type() transport.Type {
 	return transport.TypeHTTP
 }
 
+func (s *Server) Err() <-chan error {
+	return s.errCh
+}
+
+// Endpoint returns the http or https URL of the listener,
+// which is known after Start.
+func (s *Server) Endpoint() (*url.URL, error) {
+	if s.certs != nil {
+		return endpoint.URL("https", s.lis)
+	}
+	return endpoint.URL("http", s.lis)
+}
+
+// Start listens on the server address and serves in background,
+// request contexts carry the values of ctx and the Transport.
 func (s *Server) Start(ctx context.Context) error {
+	if s.certs != nil {
+		if err := s.certs.Load(); err != nil {
+			return fmt.Errorf("error load tls config: %w", err)
+		}
+	}
+	l, err := s.listen()
+	if err != nil {
+		return err
+	}
+	if u, err := s.Endpoint(); err == nil {
+		s.endpoint = u.String()
+	}
+	if s.maxConns > 0 {
+		l = netutil.LimitListener(l, s.maxConns)
+	}
+	if s.certs != nil {
+		l = tls.NewListener(l, s.certs.TLSConfig("h2", "http/1.1"))
+	}
 	log.Debugf("HTTP Server listen: %s", s.Addr)
+	s.srv.BaseContext = func(net.Listener) context.Context {
+		return ctxutil.WithValues(context.Background(), ctx)
+	}
 	go func() {
-		if err := s.srv.ListenAndServe(); err != nil {
+		if err := s.srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Errorf("error http serve: %s", err)
+			s.errCh <- fmt.Errorf("error http serve: %w", err)
 		}
 	}()
 	return nil
 }
 
+// SetEndpoint sets the Endpoint of the Transport when the server is served
+// by another one, such as mux.Server, instead of its own Start.
+func (s *Server) SetEndpoint(endpoint string) {
+	s.endpoint = endpoint
+}
+
+// listen returns the listener given by WithListener, or listens on the
+// server address. The socket file of a unix address is removed on Stop.
+func (s *Server) listen() (net.Listener, error) {
+	if s.lis != nil && s.injected {
+		return s.lis, nil
+	}
+	l, err := handoff.Listen(endpoint.ParseAddr(s.Addr))
+	if err != nil {
+		return nil, fmt.Errorf("error listen addr: %w", err)
+	}
+	s.lis = l
+	return l, nil
+}
+
+// CloseStreams ends the event streams and the websocket connections, Stop
+// calls it before the shutdown, which would wait for them otherwise.
+func (s *Server) CloseStreams() {
+	s.stopOnce.Do(func() { close(s.done) })
+}
+
+// Stop ends the event streams and stops the server gracefully within ctx,
+// it waits for the running websocket handlers as well.
 func (s *Server) Stop(ctx context.Context) error {
-	return s.srv.Shutdown(ctx)
+	s.CloseStreams()
+	err := s.srv.Shutdown(ctx)
+	// Serve may not have taken the listener yet.
+	if s.lis != nil {
+		_ = s.lis.Close()
+	}
+	if err != nil {
+		return err
+	}
+	return s.sockets.Wait(ctx)
 }
 
 type httpLog struct{}
@@ -0,0 +1,286 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tkeel-io/kit/errors"
+	"github.com/tkeel-io/kit/middleware"
+	"github.com/tkeel-io/kit/transport"
+	"google.golang.org/grpc/codes"
+)
+
+func TestServerStartBindError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	s := NewServer(l.Addr().String())
+	assert.Error(t, s.Start(context.Background()))
+}
+
+type ctxKey struct{}
+
+func TestServerBaseContext(t *testing.T) {
+	s := NewServer("127.0.0.1:0")
+	ws := new(restful.WebService)
+	ws.Route(ws.GET("/value").To(func(req *restful.Request, resp *restful.Response) {
+		v, _ := req.Request.Context().Value(ctxKey{}).(string)
+		_, _ = resp.Write([]byte(v))
+	}))
+	s.Container.Add(ws)
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+	require.NoError(t, s.Start(ctx))
+	defer s.Stop(context.Background())
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+
+	resp, err := http.Get(u.String() + "/value")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "value", string(b))
+}
+
+func TestServerEndpoint(t *testing.T) {
+	s := NewServer("127.0.0.1:0")
+	_, err := s.Endpoint()
+	assert.Error(t, err)
+
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+	assert.Equal(t, "http", u.Scheme)
+	assert.Equal(t, s.lis.Addr().String(), u.Host)
+
+	s = NewServer(":0")
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	u, err = s.Endpoint()
+	require.NoError(t, err)
+	assert.NotEqual(t, "0", u.Port())
+	assert.NotEqual(t, "::", u.Hostname())
+}
+
+func TestServerUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "http.sock")
+	s := NewServer("unix://" + path)
+	ws := new(restful.WebService)
+	ws.Route(ws.GET("/hello").To(func(req *restful.Request, resp *restful.Response) {
+		_, _ = resp.Write([]byte("hello"))
+	}))
+	s.Container.Add(ws)
+	require.NoError(t, s.Start(context.Background()))
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+	assert.Equal(t, "unix://"+path, u.String())
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return net.Dial("unix", path)
+		},
+	}}
+	resp, err := client.Get("http://unix/hello")
+	require.NoError(t, err)
+	b, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+
+	require.NoError(t, s.Stop(context.Background()))
+	assert.NoFileExists(t, path)
+}
+
+func TestServerWithListener(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := NewServer("", WithListener(l))
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+	assert.Equal(t, l.Addr().String(), u.Host)
+}
+
+func TestServerTransport(t *testing.T) {
+	s := NewServer("127.0.0.1:0")
+	ws := new(restful.WebService)
+	ws.Route(ws.GET("/users/{id}").To(func(req *restful.Request, resp *restful.Response) {
+		tr, ok := transport.FromServerContext(req.Request.Context())
+		require.True(t, ok)
+		assert.Equal(t, transport.TypeHTTP, tr.Kind())
+		assert.Equal(t, "/users/{id}", tr.Operation())
+		assert.Equal(t, "value", tr.RequestHeader().Get("X-Test"))
+		u, err := s.Endpoint()
+		require.NoError(t, err)
+		assert.Equal(t, u.String(), tr.Endpoint())
+		tr.ReplyHeader().Set("X-Reply", "reply")
+	}))
+	s.Container.Add(ws)
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, u.String()+"/users/1", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Test", "value")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "reply", resp.Header.Get("X-Reply"))
+}
+
+func TestServerMiddleware(t *testing.T) {
+	errUnauthenticated := errors.New(int(codes.Unauthenticated), "io.tkeel.UNAUTHENTICATED", "unauthenticated")
+	auth := func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if req.(*restful.Request).HeaderParameter("Authorization") == "" {
+				return nil, errUnauthenticated
+			}
+			return next(context.WithValue(ctx, ctxKey{}, "user"), req)
+		}
+	}
+	s := NewServer("127.0.0.1:0", WithMiddleware(auth))
+	ws := new(restful.WebService)
+	ws.Route(ws.GET("/user").To(func(req *restful.Request, resp *restful.Response) {
+		v, _ := req.Request.Context().Value(ctxKey{}).(string)
+		_, _ = resp.Write([]byte(v))
+	}))
+	s.Container.Add(ws)
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+
+	resp, err := http.Get(u.String() + "/user")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	req, err := http.NewRequest(http.MethodGet, u.String()+"/user", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "token")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	b, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "user", string(b))
+}
+
+func TestServerLimits(t *testing.T) {
+	s := NewServer("127.0.0.1:0", WithMaxBodyBytes(4), WithReadHeaderTimeout(100*time.Millisecond))
+	assert.Equal(t, DefaultIdleTimeout, s.srv.IdleTimeout)
+	echo := func(req *restful.Request, resp *restful.Response) {
+		b, err := io.ReadAll(req.Request.Body)
+		if err != nil {
+			resp.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		_, _ = resp.Write(b)
+	}
+	ws := new(restful.WebService)
+	ws.Route(ws.POST("/echo").To(echo))
+	ws.Route(ws.POST("/upload").Filter(MaxBodyBytes(8)).To(echo))
+	ws.Route(ws.POST("/large").Filter(MaxBodyBytes(1 << 20)).To(echo))
+	s.Container.Add(ws)
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+
+	post := func(path, body string, chunked bool) int {
+		req, err := http.NewRequest(http.MethodPost, u.String()+path, strings.NewReader(body))
+		require.NoError(t, err)
+		if chunked {
+			req.ContentLength = -1
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusOK, post("/echo", "1234", false))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post("/echo", "12345", false))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post("/echo", "12345", true))
+	assert.Equal(t, http.StatusOK, post("/upload", "12345678", true))
+	assert.Equal(t, http.StatusOK, post("/upload", "12345678", false))
+	assert.Equal(t, http.StatusOK, post("/large", strings.Repeat("1", 100), false))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post("/upload", "123456789", false))
+
+	// A connection sending no headers is closed after ReadHeaderTimeout.
+	http.DefaultClient.CloseIdleConnections()
+	conn, err := net.Dial("tcp", u.Host)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	_, err = conn.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestServerMaxConns(t *testing.T) {
+	s := NewServer("127.0.0.1:0", WithMaxConns(1))
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+
+	conn, err := net.Dial("tcp", u.Host)
+	require.NoError(t, err)
+	client := &http.Client{Transport: &http.Transport{}, Timeout: 200 * time.Millisecond}
+	_, err = client.Get(u.String())
+	assert.Error(t, err)
+
+	conn.Close()
+	client.Timeout = time.Second
+	resp, err := client.Get(u.String())
+	require.NoError(t, err)
+	resp.Body.Close()
+}
+
+func TestServerHeaderContext(t *testing.T) {
+	s := NewServer("127.0.0.1:0")
+	ws := new(restful.WebService)
+	ws.Route(ws.GET("/users/{id}").To(func(req *restful.Request, resp *restful.Response) {
+		ctx := req.Request.Context()
+		assert.Equal(t, "token", HeaderFromContext(ctx).Get("Authorization"))
+		info, ok := RequestInfoFromContext(ctx)
+		if !assert.True(t, ok) {
+			return
+		}
+		assert.Equal(t, &RequestInfo{
+			Method:     http.MethodGet,
+			Path:       "/users/1",
+			Operation:  "/users/{id}",
+			PathParams: map[string]string{"id": "1"},
+			Query:      url.Values{"q": {"v"}},
+		}, info)
+	}))
+	s.Container.Add(ws)
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, u.String()+"/users/1?q=v", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "token")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,176 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"github.com/tkeel-io/kit/encoding"
+	"github.com/tkeel-io/kit/transport"
+)
+
+// ErrStreamClosed is returned by EventStream.Send after the stream is closed.
+var ErrStreamClosed = errors.New("event stream closed")
+
+// Event is a Server-Sent Event.
+type Event struct {
+	// ID is the event id, which the client sends back as Last-Event-ID
+	// when it reconnects.
+	ID string
+	// Event is the event type, the client dispatches it as "message" when empty.
+	Event string
+	// Data is the payload, proto.Message is encoded with protojson, string
+	// and []byte are sent as is, the others are encoded with encoding/json.
+	Data interface{}
+	// Retry tells the client how long to wait before reconnecting.
+	Retry time.Duration
+}
+
+// EventStream is a Server-Sent Events stream of a route, for example:
+//
+//	ws.Route(ws.GET("/events").Produces("text/event-stream").To(func(req *restful.Request, resp *restful.Response) {
+//		es, err := NewEventStream(req, resp)
+//		if err != nil {
+//			return
+//		}
+//		defer es.Close()
+//		for {
+//			select {
+//			case <-es.Context().Done():
+//				return
+//			case e := <-events:
+//				if err := es.Send(e); err != nil {
+//					return
+//				}
+//			}
+//		}
+//	}))
+type EventStream struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	resp        *restful.Response
+	flusher     http.Flusher
+	lastEventID string
+
+	mu sync.Mutex
+}
+
+// NewEventStream writes the event stream headers to resp, the route should
+// produce text/event-stream. The request must accept text/event-stream, as
+// EventSource does, otherwise the response may be compressed and can not
+// be flushed.
+func NewEventStream(req *restful.Request, resp *restful.Response) (*EventStream, error) {
+	if _, ok := resp.ResponseWriter.(*restful.CompressingResponseWriter); ok {
+		return nil, errors.New("error new event stream: compressed response can not be flushed")
+	}
+	flusher, ok := resp.ResponseWriter.(http.Flusher)
+	if !ok {
+		return nil, errors.New("error new event stream: response writer is not a http.Flusher")
+	}
+
+	ctx, cancel := context.WithCancel(req.Request.Context())
+	if tr, ok := transport.FromServerContext(ctx); ok {
+		if ht, ok := tr.(*Transport); ok && ht.done != nil {
+			go func() {
+				select {
+				case <-ht.done:
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+		}
+	}
+
+	h := resp.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no")
+	resp.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	return &EventStream{
+		ctx:         ctx,
+		cancel:      cancel,
+		resp:        resp,
+		flusher:     flusher,
+		lastEventID: req.HeaderParameter("Last-Event-ID"),
+	}, nil
+}
+
+// Context returns the context of the stream, which is canceled when the
+// client goes away, the server stops or the stream is closed.
+func (es *EventStream) Context() context.Context {
+	return es.ctx
+}
+
+// LastEventID returns the id of the last event received by a reconnecting
+// client, the stream should resume after it.
+func (es *EventStream) LastEventID() string {
+	return es.lastEventID
+}
+
+// Send writes and flushes e, it is safe for concurrent use.
+func (es *EventStream) Send(e *Event) error {
+	b, err := encodeEvent(e)
+	if err != nil {
+		return err
+	}
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if es.ctx.Err() != nil {
+		return ErrStreamClosed
+	}
+	if _, err := es.resp.Write(b); err != nil {
+		return fmt.Errorf("error write event: %w", err)
+	}
+	es.flusher.Flush()
+	return nil
+}
+
+// Close ends the stream, the handler returns afterwards.
+func (es *EventStream) Close() {
+	es.cancel()
+}
+
+func encodeEvent(e *Event) ([]byte, error) {
+	var data []byte
+	switch v := e.Data.(type) {
+	case nil:
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		b, err := encoding.JSONCodec{}.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("error marshal event data: %w", err)
+		}
+		data = b
+	}
+
+	var buf bytes.Buffer
+	if e.ID != "" {
+		buf.WriteString("id: " + strings.ReplaceAll(e.ID, "\n", "") + "\n")
+	}
+	if e.Event != "" {
+		buf.WriteString("event: " + strings.ReplaceAll(e.Event, "\n", "") + "\n")
+	}
+	if e.Retry > 0 {
+		buf.WriteString("retry: " + strconv.FormatInt(e.Retry.Milliseconds(), 10) + "\n")
+	}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
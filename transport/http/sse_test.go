@@ -0,0 +1,65 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestEventStream(t *testing.T) {
+	s := NewServer("127.0.0.1:0")
+	ws := new(restful.WebService)
+	ws.Route(ws.GET("/events").Produces("text/event-stream").To(func(req *restful.Request, resp *restful.Response) {
+		es, err := NewEventStream(req, resp)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer es.Close()
+		assert.NoError(t, es.Send(&Event{ID: "2", Event: "resume", Data: es.LastEventID(), Retry: time.Second}))
+		assert.NoError(t, es.Send(&Event{ID: "3", Data: wrapperspb.String("proto")}))
+		assert.NoError(t, es.Send(&Event{Data: map[string]string{"k": "v"}}))
+		<-es.Context().Done()
+		assert.ErrorIs(t, es.Send(&Event{}), ErrStreamClosed)
+	}))
+	s.Container.Add(ws)
+	require.NoError(t, s.Start(context.Background()))
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, u.String()+"/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "1")
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	r := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 10 {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		lines = append(lines, line)
+	}
+	assert.Equal(t, []string{
+		"id: 2\n", "event: resume\n", "retry: 1000\n", "data: 1\n", "\n",
+		"id: 3\n", "data: \"proto\"\n", "\n",
+		"data: {\"k\":\"v\"}\n", "\n",
+	}, lines)
+
+	// Stop ends the stream instead of waiting for it.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, s.Stop(ctx))
+	_, err = r.ReadString('\n')
+	assert.Error(t, err)
+}
@@ -0,0 +1,185 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	"github.com/tkeel-io/kit/internal/inflight"
+	"github.com/tkeel-io/kit/log"
+	"github.com/tkeel-io/kit/transport"
+)
+
+var _ transport.Transporter = (*Transport)(nil)
+
+// Transport is the HTTP Transporter.
+type Transport struct {
+	endpoint    string
+	operation   string
+	reqHeader   headerCarrier
+	replyHeader headerCarrier
+	request     *restful.Request
+	response    *restful.Response
+	// body is the request body without the size limit.
+	body io.ReadCloser
+	// done is closed when the server stops.
+	done <-chan struct{}
+	// sockets counts the running websocket handlers of the server.
+	sockets *inflight.Counter
+}
+
+func (tr *Transport) Kind() transport.Type {
+	return transport.TypeHTTP
+}
+
+func (tr *Transport) Endpoint() string {
+	return tr.endpoint
+}
+
+func (tr *Transport) Operation() string {
+	return tr.operation
+}
+
+func (tr *Transport) RequestHeader() transport.Header {
+	return tr.reqHeader
+}
+
+func (tr *Transport) ReplyHeader() transport.Header {
+	return tr.replyHeader
+}
+
+// Request returns the go-restful request.
+func (tr *Transport) Request() *restful.Request {
+	return tr.request
+}
+
+// Response returns the go-restful response.
+func (tr *Transport) Response() *restful.Response {
+	return tr.response
+}
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string {
+	return http.Header(hc).Get(key)
+}
+
+func (hc headerCarrier) Set(key string, value string) {
+	http.Header(hc).Set(key, value)
+}
+
+func (hc headerCarrier) Add(key string, value string) {
+	http.Header(hc).Add(key, value)
+}
+
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range hc {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (hc headerCarrier) Values(key string) []string {
+	return http.Header(hc).Values(key)
+}
+
+// transportFilter stores the Transport in the request context and limits
+// the size of the request body. The body is only wrapped, so that the
+// MaxBodyBytes filter of the route can raise the limit.
+func (s *Server) transportFilter(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+	body := req.Request.Body
+	if s.maxBodyBytes > 0 {
+		req.Request.Body = http.MaxBytesReader(resp, body, s.maxBodyBytes)
+	}
+	tr := &Transport{
+		endpoint:    s.endpoint,
+		operation:   req.SelectedRoutePath(),
+		reqHeader:   headerCarrier(req.Request.Header),
+		replyHeader: headerCarrier(resp.Header()),
+		request:     req,
+		response:    resp,
+		body:        body,
+		done:        s.done,
+		sockets:     &s.sockets,
+	}
+	req.Request = req.Request.WithContext(transport.NewServerContext(req.Request.Context(), tr))
+	chain.ProcessFilter(req, resp)
+}
+
+// MaxBodyBytes returns a route filter which limits the size of the request
+// body to n instead of the server limit, zero means no limit. It replies
+// 413 if the Content-Length is larger than n.
+//
+//	ws.Route(ws.POST("/firmwares").Filter(MaxBodyBytes(1 << 30)).To(upload))
+func MaxBodyBytes(n int64) restful.FilterFunction {
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		body := req.Request.Body
+		if tr, ok := transport.FromServerContext(req.Request.Context()); ok {
+			if ht, ok := tr.(*Transport); ok {
+				body = ht.body
+			}
+		}
+		if limitBody(req, resp, body, n) {
+			chain.ProcessFilter(req, resp)
+		}
+	}
+}
+
+// limitBody replaces the request body with body limited to n bytes, it
+// replies 413 and returns false if the Content-Length is larger than n.
+func limitBody(req *restful.Request, resp *restful.Response, body io.ReadCloser, n int64) bool {
+	if n <= 0 {
+		req.Request.Body = body
+		return true
+	}
+	if req.Request.ContentLength > n {
+		if err := resp.WriteErrorString(http.StatusRequestEntityTooLarge, "request body too large"); err != nil {
+			log.Errorf("error write error response: %s", err)
+		}
+		return false
+	}
+	req.Request.Body = http.MaxBytesReader(resp, body, n)
+	return true
+}
+
+// filterChainAttribute is the request attribute holding the filterCall
+// of the middleware filter.
+const filterChainAttribute = "github.com/tkeel-io/kit/transport/http.filterCall"
+
+// filterCall is the rest of the filter chain of a request.
+type filterCall struct {
+	resp  *restful.Response
+	chain *restful.FilterChain
+}
+
+// middlewareFilter calls the rest of the filter chain through the server
+// middleware, an error returned by the middleware is written to resp.
+func (s *Server) middlewareFilter(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+	req.SetAttribute(filterChainAttribute, &filterCall{resp: resp, chain: chain})
+	if _, err := s.handler(req.Request.Context(), req); err != nil {
+		writeError(resp, err)
+	}
+}
+
+// processFilterChain is the innermost Handler of the server middleware,
+// it calls the rest of the filter chain with ctx.
+func processFilterChain(ctx context.Context, r interface{}) (interface{}, error) {
+	req := r.(*restful.Request)
+	call := req.Attribute(filterChainAttribute).(*filterCall)
+	req.Request = req.Request.WithContext(ctx)
+	call.chain.ProcessFilter(req, call.resp)
+	return nil, nil
+}
+
+// writeError writes err in the result.Http envelope, see WriteResult.
+// If the handler has started the response, err is only logged, so that
+// the body does not get a second envelope.
+func writeError(resp *restful.Response, err error) {
+	if resp.StatusCode() != http.StatusOK || resp.ContentLength() > 0 {
+		log.Errorf("error after the response is written: %s", err)
+		return
+	}
+	WriteResult(resp, nil, err)
+}
@@ -0,0 +1,248 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"github.com/gorilla/websocket"
+	"github.com/tkeel-io/kit/encoding"
+	"github.com/tkeel-io/kit/log"
+	"github.com/tkeel-io/kit/transport"
+)
+
+const (
+	// DefaultPingInterval is the default interval of websocket pings.
+	DefaultPingInterval = 30 * time.Second
+	// DefaultPongWait is the default time to wait for a pong after a ping.
+	DefaultPongWait = 10 * time.Second
+
+	closeWait = time.Second
+)
+
+// WebSocketCodec encodes and decodes websocket messages.
+type WebSocketCodec interface {
+	// MessageType returns websocket.TextMessage or websocket.BinaryMessage.
+	MessageType() int
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	// JSONCodec sends text messages, proto.Message is encoded with protojson.
+	JSONCodec WebSocketCodec = jsonCodec{}
+	// ProtoCodec sends binary messages of proto.Message.
+	ProtoCodec WebSocketCodec = protoCodec{}
+)
+
+type jsonCodec struct {
+	encoding.JSONCodec
+}
+
+func (jsonCodec) MessageType() int {
+	return websocket.TextMessage
+}
+
+type protoCodec struct {
+	encoding.ProtoCodec
+}
+
+func (protoCodec) MessageType() int {
+	return websocket.BinaryMessage
+}
+
+// WebSocketOption is a websocket option.
+type WebSocketOption func(o *webSocketOptions)
+
+type webSocketOptions struct {
+	codec        WebSocketCodec
+	pingInterval time.Duration
+	pongWait     time.Duration
+	upgrader     websocket.Upgrader
+}
+
+// WithWebSocketCodec sets the message codec, JSONCodec by default.
+func WithWebSocketCodec(c WebSocketCodec) WebSocketOption {
+	return func(o *webSocketOptions) { o.codec = c }
+}
+
+// WithPingInterval sets the interval of pings and the time to wait for a
+// pong, the connection is closed when no pong is received in time.
+func WithPingInterval(interval, pongWait time.Duration) WebSocketOption {
+	return func(o *webSocketOptions) {
+		o.pingInterval = interval
+		o.pongWait = pongWait
+	}
+}
+
+// WithCheckOrigin sets the check of the Origin header,
+// by default the origin host must equal the request host.
+func WithCheckOrigin(f func(r *http.Request) bool) WebSocketOption {
+	return func(o *webSocketOptions) { o.upgrader.CheckOrigin = f }
+}
+
+// WebSocketHandler handles a websocket connection, the connection is
+// closed when the handler returns.
+type WebSocketHandler func(conn *WebSocketConn)
+
+// WebSocket returns a route function upgrading the requests to websocket
+// connections handled by h. The filters of the route run before the
+// upgrade, so the context of the connection carries the values they store,
+// such as the authenticated user stored with auth.ContextWithUser. All
+// connections are closed on Server.Stop.
+//
+//	ws.Route(ws.GET("/devices/{id}/control").To(WebSocket(func(conn *WebSocketConn) {
+//		for {
+//			in := new(pb.Command)
+//			if err := conn.ReadMessage(in); err != nil {
+//				return
+//			}
+//			...
+//		}
+//	})))
+func WebSocket(h WebSocketHandler, opts ...WebSocketOption) restful.RouteFunction {
+	o := webSocketOptions{
+		codec:        JSONCodec,
+		pingInterval: DefaultPingInterval,
+		pongWait:     DefaultPongWait,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(req *restful.Request, resp *restful.Response) {
+		if tr, ok := transport.FromServerContext(req.Request.Context()); ok {
+			if ht, ok := tr.(*Transport); ok && ht.sockets != nil {
+				ht.sockets.Add()
+				defer ht.sockets.Done()
+			}
+		}
+		wsConn, err := o.upgrader.Upgrade(resp.ResponseWriter, req.Request, nil)
+		if err != nil {
+			// the upgrader has replied with an error.
+			log.Debugf("error websocket upgrade: %s", err)
+			return
+		}
+		conn := newWebSocketConn(req.Request.Context(), wsConn, &o)
+		defer conn.Close()
+		h(conn)
+	}
+}
+
+// WebSocketConn is a websocket connection, it is safe to read in one
+// goroutine and write in others concurrently.
+type WebSocketConn struct {
+	conn   *websocket.Conn
+	codec  WebSocketCodec
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	writeMu   sync.Mutex
+	closeOnce sync.Once
+}
+
+func newWebSocketConn(ctx context.Context, wsConn *websocket.Conn, o *webSocketOptions) *WebSocketConn {
+	ctx, cancel := context.WithCancel(ctx)
+	c := &WebSocketConn{
+		conn:   wsConn,
+		codec:  o.codec,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	readTimeout := o.pingInterval + o.pongWait
+	_ = wsConn.SetReadDeadline(time.Now().Add(readTimeout))
+	wsConn.SetPongHandler(func(string) error {
+		return wsConn.SetReadDeadline(time.Now().Add(readTimeout))
+	})
+
+	var done <-chan struct{}
+	if tr, ok := transport.FromServerContext(ctx); ok {
+		if ht, ok := tr.(*Transport); ok {
+			done = ht.done
+		}
+	}
+	go c.keepalive(o.pingInterval, done)
+	return c
+}
+
+// keepalive pings the peer until the connection is closed,
+// and closes the connection when the server stops.
+func (c *WebSocketConn) keepalive(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.writeMu.Lock()
+			err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(closeWait))
+			c.writeMu.Unlock()
+			if err != nil {
+				c.Close()
+				return
+			}
+		case <-done:
+			c.closeWithCode(websocket.CloseGoingAway, "server stopping")
+			return
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// Context returns the context of the connection, which carries the values
+// of the request context and is canceled when the connection is closed.
+func (c *WebSocketConn) Context() context.Context {
+	return c.ctx
+}
+
+// ReadMessage reads a message and decodes it into v.
+func (c *WebSocketConn) ReadMessage(v interface{}) error {
+	_, data, err := c.conn.ReadMessage()
+	if err != nil {
+		c.Close()
+		return fmt.Errorf("error read message: %w", err)
+	}
+	if err := c.codec.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("error unmarshal message: %w", err)
+	}
+	return nil
+}
+
+// WriteMessage encodes v and writes it as a message.
+func (c *WebSocketConn) WriteMessage(v interface{}) error {
+	data, err := c.codec.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("error marshal message: %w", err)
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := c.conn.WriteMessage(c.codec.MessageType(), data); err != nil {
+		return fmt.Errorf("error write message: %w", err)
+	}
+	return nil
+}
+
+// Close sends a normal closure to the peer and closes the connection.
+func (c *WebSocketConn) Close() error {
+	return c.closeWithCode(websocket.CloseNormalClosure, "")
+}
+
+func (c *WebSocketConn) closeWithCode(code int, text string) error {
+	var err error
+	c.closeOnce.Do(func() {
+		c.cancel()
+		c.writeMu.Lock()
+		werr := c.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(code, text), time.Now().Add(closeWait))
+		c.writeMu.Unlock()
+		if werr != nil && !errors.Is(werr, websocket.ErrCloseSent) {
+			log.Debugf("error write websocket close: %s", werr)
+		}
+		err = c.conn.Close()
+	})
+	return err
+}
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestWebSocket(t *testing.T) {
+	s := NewServer("127.0.0.1:0")
+	ws := new(restful.WebService)
+	ws.Filter(func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		req.Request = req.Request.WithContext(context.WithValue(req.Request.Context(), ctxKey{}, "u1"))
+		chain.ProcessFilter(req, resp)
+	})
+	closed := make(chan struct{})
+	ws.Route(ws.GET("/echo").To(WebSocket(func(conn *WebSocketConn) {
+		user, _ := conn.Context().Value(ctxKey{}).(string)
+		for {
+			in := new(wrapperspb.StringValue)
+			if err := conn.ReadMessage(in); err != nil {
+				break
+			}
+			assert.NoError(t, conn.WriteMessage(wrapperspb.String(user+":"+in.Value)))
+		}
+		<-conn.Context().Done()
+		close(closed)
+	}, WithWebSocketCodec(ProtoCodec))))
+	ws.Route(ws.GET("/json").To(WebSocket(func(conn *WebSocketConn) {
+		assert.NoError(t, conn.WriteMessage(map[string]string{"k": "v"}))
+	})))
+	s.Container.Add(ws)
+	require.NoError(t, s.Start(context.Background()))
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+	addr := "ws" + strings.TrimPrefix(u.String(), "http")
+
+	c, _, err := websocket.DefaultDialer.Dial(addr+"/json", nil)
+	require.NoError(t, err)
+	typ, data, err := c.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, websocket.TextMessage, typ)
+	assert.JSONEq(t, `{"k":"v"}`, string(data))
+	_, _, err = c.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+	c.Close()
+
+	c, _, err = websocket.DefaultDialer.Dial(addr+"/echo", nil)
+	require.NoError(t, err)
+	defer c.Close()
+	out, err := ProtoCodec.Marshal(wrapperspb.String("ping"))
+	require.NoError(t, err)
+	require.NoError(t, c.WriteMessage(websocket.BinaryMessage, out))
+	typ, data, err = c.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, websocket.BinaryMessage, typ)
+	in := new(wrapperspb.StringValue)
+	require.NoError(t, ProtoCodec.Unmarshal(data, in))
+	assert.Equal(t, "u1:ping", in.Value)
+
+	// Stop closes the open sockets and waits for their handlers.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, s.Stop(ctx))
+	_, _, err = c.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway))
+	select {
+	case <-closed:
+	default:
+		t.Fatal("handler is not done")
+	}
+}
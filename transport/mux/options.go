@@ -0,0 +1,58 @@
+package mux
+
+import (
+	"time"
+)
+
+// ServerOption is a server option. The timeouts apply to the HTTP/1
+// requests, the HTTP/2 connections, including the gRPC ones, only get
+// the idle timeout.
+type ServerOption func(s *Server)
+
+// WithReadHeaderTimeout sets the time to read the request headers,
+// transportHTTP.DefaultReadHeaderTimeout by default, zero means no timeout.
+func WithReadHeaderTimeout(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.srv.ReadHeaderTimeout = d
+	}
+}
+
+// WithReadTimeout sets the time to read an entire request including the
+// body, transportHTTP.DefaultReadTimeout by default, zero means no timeout.
+func WithReadTimeout(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.srv.ReadTimeout = d
+	}
+}
+
+// WithWriteTimeout sets the time to write a response, no timeout by default.
+// The timeout also ends event streams living longer than d.
+func WithWriteTimeout(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.srv.WriteTimeout = d
+	}
+}
+
+// WithIdleTimeout sets the time to wait for the next request on a
+// keep-alive connection, transportHTTP.DefaultIdleTimeout by default.
+func WithIdleTimeout(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.srv.IdleTimeout = d
+	}
+}
+
+// WithMaxHeaderBytes sets the max size of the request headers,
+// transportHTTP.DefaultMaxHeaderBytes by default.
+func WithMaxHeaderBytes(n int) ServerOption {
+	return func(s *Server) {
+		s.srv.MaxHeaderBytes = n
+	}
+}
+
+// WithMaxConns limits the number of concurrent connections, the listener
+// stops accepting until a connection is closed. No limit by default.
+func WithMaxConns(n int) ServerOption {
+	return func(s *Server) {
+		s.maxConns = n
+	}
+}
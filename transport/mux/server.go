@@ -0,0 +1,161 @@
+// Package mux serves the gRPC server and the go-restful container of the
+// transport packages on a single port.
+package mux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/tkeel-io/kit/internal/ctxutil"
+	"github.com/tkeel-io/kit/internal/endpoint"
+	"github.com/tkeel-io/kit/internal/inflight"
+	"github.com/tkeel-io/kit/log"
+	"github.com/tkeel-io/kit/transport"
+	transportGRPC "github.com/tkeel-io/kit/transport/grpc"
+	"github.com/tkeel-io/kit/transport/handoff"
+	transportHTTP "github.com/tkeel-io/kit/transport/http"
+	"go.uber.org/multierr"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"golang.org/x/net/netutil"
+)
+
+const DefaultPort = ":31234"
+
+var (
+	_ transport.ErrorNotifier = (*Server)(nil)
+	_ transport.Endpointer    = (*Server)(nil)
+)
+
+// Server routes the connections of one listener to the gRPC server or the
+// HTTP server: HTTP/2 requests with an application/grpc content type go to
+// the gRPC server, the others go to the HTTP server.
+// HTTP/2 is served over cleartext too, so gRPC clients need no TLS.
+//
+// The gRPC and HTTP servers must not be started on their own, the Server
+// stops both of them on Stop.
+type Server struct {
+	Addr  string
+	srv   *http.Server
+	errCh chan error
+	lis   net.Listener
+	// conns are the accepted connections, including the HTTP/2 ones which
+	// h2c hijacks from srv, so that Shutdown does not wait for them.
+	conns    connSet
+	handlers inflight.Counter
+	// maxConns limits the concurrent connections, no limit if not positive.
+	maxConns int
+
+	grpcSrv *transportGRPC.Server
+	httpSrv *transportHTTP.Server
+}
+
+func NewServer(addr string, grpcSrv *transportGRPC.Server, httpSrv *transportHTTP.Server, opts ...ServerOption) *Server {
+	if addr == "" {
+		addr = DefaultPort
+	}
+	s := &Server{
+		Addr:    addr,
+		errCh:   make(chan error, 1),
+		grpcSrv: grpcSrv,
+		httpSrv: httpSrv,
+	}
+	h2s := &http2.Server{}
+	s.srv = &http.Server{
+		Addr:              addr,
+		Handler:           h2c.NewHandler(http.HandlerFunc(s.serveHTTP), h2s),
+		ReadHeaderTimeout: transportHTTP.DefaultReadHeaderTimeout,
+		ReadTimeout:       transportHTTP.DefaultReadTimeout,
+		IdleTimeout:       transportHTTP.DefaultIdleTimeout,
+		MaxHeaderBytes:    transportHTTP.DefaultMaxHeaderBytes,
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	// makes Shutdown send GOAWAY to the HTTP/2 connections.
+	if err := http2.ConfigureServer(s.srv, h2s); err != nil {
+		log.Errorf("error configure http2 server: %s", err)
+	}
+	return s
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handlers.Add()
+	defer s.handlers.Done()
+	if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		s.grpcSrv.GetServe().ServeHTTP(w, r)
+		return
+	}
+	s.httpSrv.ServeHTTP(w, r)
+}
+
+func (s *Server) Type() transport.Type {
+	return transport.TypeMux
+}
+
+func (s *Server) Err() <-chan error {
+	return s.errCh
+}
+
+// Endpoint returns the http URL of the listener, which is known after Start.
+// gRPC is served on the same host and port.
+func (s *Server) Endpoint() (*url.URL, error) {
+	return endpoint.URL("http", s.lis)
+}
+
+// Start listens on the server address and serves in background,
+// request contexts carry the values of ctx. The Transport endpoints of
+// the HTTP and gRPC servers are set to the http and grpc URLs of the
+// listener.
+func (s *Server) Start(ctx context.Context) error {
+	l, err := handoff.Listen("tcp", s.Addr)
+	if err != nil {
+		return fmt.Errorf("error listen addr: %w", err)
+	}
+	s.lis = l
+	if u, err := s.Endpoint(); err == nil {
+		s.httpSrv.SetEndpoint(u.String())
+		u.Scheme = "grpc"
+		s.grpcSrv.SetEndpoint(u.String())
+	}
+	if s.maxConns > 0 {
+		l = netutil.LimitListener(l, s.maxConns)
+	}
+	l = &trackListener{Listener: l, conns: &s.conns}
+	log.Debugf("MUX Server listen: %s", s.Addr)
+	s.srv.BaseContext = func(net.Listener) context.Context {
+		return ctxutil.WithValues(context.Background(), ctx)
+	}
+	go func() {
+		if err := s.srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("error mux serve: %s", err)
+			s.errCh <- fmt.Errorf("error mux serve: %w", err)
+		}
+	}()
+	return nil
+}
+
+// Stop ends the event streams and the websocket connections of the HTTP
+// server, stops accepting connections and sends GOAWAY to the HTTP/2
+// connections, then waits within ctx for the running requests, the
+// connections left are closed when ctx is done. The gRPC server is served
+// through ServeHTTP, which does not support GracefulStop, so it is stopped
+// only after its requests are drained.
+func (s *Server) Stop(ctx context.Context) error {
+	s.httpSrv.CloseStreams()
+	err := s.srv.Shutdown(ctx)
+	if err == nil {
+		err = s.conns.wait(ctx)
+	}
+	if err == nil {
+		err = s.handlers.Wait(ctx)
+	}
+	s.conns.closeAll()
+	s.grpcSrv.GetServe().Stop()
+	return multierr.Append(err, s.httpSrv.Stop(ctx))
+}
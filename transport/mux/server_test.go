@@ -0,0 +1,224 @@
+package mux
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tkeel-io/kit/middleware"
+	"github.com/tkeel-io/kit/transport"
+	transportGRPC "github.com/tkeel-io/kit/transport/grpc"
+	transportHTTP "github.com/tkeel-io/kit/transport/http"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestServer(t *testing.T) {
+	grpcSrv := transportGRPC.NewServer("")
+	grpc_health_v1.RegisterHealthServer(grpcSrv.GetServe(), health.NewServer())
+	httpSrv := transportHTTP.NewServer("")
+	ws := new(restful.WebService)
+	ws.Route(ws.GET("/hello").To(func(req *restful.Request, resp *restful.Response) {
+		_, _ = resp.Write([]byte("hello"))
+	}))
+	httpSrv.Container.Add(ws)
+
+	s := NewServer("127.0.0.1:0", grpcSrv, httpSrv)
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+
+	resp, err := http.Get(u.String() + "/hello")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, u.Host, grpc.WithInsecure(), grpc.WithBlock())
+	require.NoError(t, err)
+	defer conn.Close()
+	reply, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, reply.Status)
+}
+
+// blockingHealth blocks Check until release is closed, and Watch until its
+// stream is done.
+type blockingHealth struct {
+	grpc_health_v1.UnimplementedHealthServer
+	started chan struct{}
+	release chan struct{}
+}
+
+func (h *blockingHealth) Check(ctx context.Context, _ *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	h.started <- struct{}{}
+	<-h.release
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+func (h *blockingHealth) Watch(_ *grpc_health_v1.HealthCheckRequest, ss grpc_health_v1.Health_WatchServer) error {
+	if err := ss.Send(&grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}); err != nil {
+		return err
+	}
+	<-ss.Context().Done()
+	return ss.Context().Err()
+}
+
+func TestServerStopInFlight(t *testing.T) {
+	grpcSrv := transportGRPC.NewServer("")
+	h := &blockingHealth{started: make(chan struct{}, 1), release: make(chan struct{})}
+	grpc_health_v1.RegisterHealthServer(grpcSrv.GetServe(), h)
+	s := NewServer("127.0.0.1:0", grpcSrv, transportHTTP.NewServer(""))
+	require.NoError(t, s.Start(context.Background()))
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+
+	conn, err := grpc.Dial(u.Host, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+	client := grpc_health_v1.NewHealthClient(conn)
+
+	stream, err := client.Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	unaryErr := make(chan error, 1)
+	go func() {
+		_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		unaryErr <- err
+	}()
+	<-h.started
+
+	stopErr := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+		stopErr <- s.Stop(ctx)
+	}()
+	// the unary call in flight is drained.
+	time.Sleep(100 * time.Millisecond)
+	close(h.release)
+	assert.NoError(t, <-unaryErr)
+
+	// the stream is closed when the stop timeout is reached.
+	select {
+	case err := <-stopErr:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop does not return")
+	}
+	_, err = stream.Recv()
+	assert.Error(t, err)
+}
+
+func TestServerStopEventStream(t *testing.T) {
+	httpSrv := transportHTTP.NewServer("")
+	ws := new(restful.WebService)
+	ws.Route(ws.GET("/events").Produces("text/event-stream").To(func(req *restful.Request, resp *restful.Response) {
+		es, err := transportHTTP.NewEventStream(req, resp)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer es.Close()
+		assert.NoError(t, es.Send(&transportHTTP.Event{Data: "hello"}))
+		<-es.Context().Done()
+	}))
+	httpSrv.Container.Add(ws)
+	s := NewServer("127.0.0.1:0", transportGRPC.NewServer(""), httpSrv)
+	require.NoError(t, s.Start(context.Background()))
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, u.String()+"/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "data: hello\n", line)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	start := time.Now()
+	assert.NoError(t, s.Stop(ctx))
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestServerLimits(t *testing.T) {
+	s := NewServer("127.0.0.1:0", transportGRPC.NewServer(""), transportHTTP.NewServer(""),
+		WithReadTimeout(time.Second), WithMaxConns(1))
+	assert.Equal(t, transportHTTP.DefaultReadHeaderTimeout, s.srv.ReadHeaderTimeout)
+	assert.Equal(t, time.Second, s.srv.ReadTimeout)
+	assert.Equal(t, transportHTTP.DefaultIdleTimeout, s.srv.IdleTimeout)
+	assert.Equal(t, transportHTTP.DefaultMaxHeaderBytes, s.srv.MaxHeaderBytes)
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+
+	conn, err := net.Dial("tcp", u.Host)
+	require.NoError(t, err)
+	client := &http.Client{Transport: &http.Transport{}, Timeout: 200 * time.Millisecond}
+	_, err = client.Get(u.String())
+	assert.Error(t, err)
+
+	conn.Close()
+	client.Timeout = time.Second
+	resp, err := client.Get(u.String())
+	require.NoError(t, err)
+	resp.Body.Close()
+}
+
+func TestServerTransportEndpoint(t *testing.T) {
+	endpoints := make(chan string, 1)
+	grpcSrv := transportGRPC.NewServer("", transportGRPC.WithMiddleware(func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if tr, ok := transport.FromServerContext(ctx); ok {
+				endpoints <- tr.Endpoint()
+			}
+			return next(ctx, req)
+		}
+	}))
+	grpc_health_v1.RegisterHealthServer(grpcSrv.GetServe(), health.NewServer())
+	httpSrv := transportHTTP.NewServer("")
+	ws := new(restful.WebService)
+	ws.Route(ws.GET("/endpoint").To(func(req *restful.Request, resp *restful.Response) {
+		tr, _ := transport.FromServerContext(req.Request.Context())
+		_, _ = resp.Write([]byte(tr.Endpoint()))
+	}))
+	httpSrv.Container.Add(ws)
+	s := NewServer("127.0.0.1:0", grpcSrv, httpSrv)
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	u, err := s.Endpoint()
+	require.NoError(t, err)
+
+	resp, err := http.Get(u.String() + "/endpoint")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, u.String(), string(b))
+
+	conn, err := grpc.Dial(u.Host, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "grpc://"+u.Host, <-endpoints)
+}
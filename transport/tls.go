@@ -0,0 +1,165 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/tkeel-io/kit/log"
+)
+
+// DefaultReloadInterval is the default interval of checking the
+// certificate files for changes.
+const DefaultReloadInterval = 10 * time.Second
+
+// TLSConfig is the TLS configuration of HTTP and gRPC servers.
+type TLSConfig struct {
+	// CertFile and KeyFile are the PEM encoded server certificate and key.
+	CertFile string
+	KeyFile  string
+	// ClientCAFile is the PEM encoded CA bundle verifying client certificates.
+	ClientCAFile string
+	// ClientAuth is the policy for client certificates, set it to
+	// tls.RequireAndVerifyClientCert for mutual TLS.
+	ClientAuth tls.ClientAuthType
+	// MinVersion is the minimum TLS version, TLS 1.2 by default.
+	MinVersion uint16
+	// ReloadInterval is the minimum interval of checking the files for
+	// changes, DefaultReloadInterval by default.
+	ReloadInterval time.Duration
+}
+
+// CertReloader serves the certificates of a TLSConfig, and reloads them
+// when the files change on disk, so rotated certificates are used without
+// restarting the server.
+type CertReloader struct {
+	conf TLSConfig
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  map[string]time.Time
+	checkedAt time.Time
+}
+
+func NewCertReloader(c *TLSConfig) *CertReloader {
+	conf := *c
+	if conf.MinVersion == 0 {
+		conf.MinVersion = tls.VersionTLS12
+	}
+	if conf.ReloadInterval <= 0 {
+		conf.ReloadInterval = DefaultReloadInterval
+	}
+	return &CertReloader{conf: conf}
+}
+
+// Load loads the certificate files, call it before serving
+// to report invalid files early.
+func (r *CertReloader) Load() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.load()
+}
+
+// TLSConfig returns a server tls.Config negotiating protos with ALPN.
+func (r *CertReloader) TLSConfig(protos ...string) *tls.Config {
+	return &tls.Config{
+		MinVersion: r.conf.MinVersion,
+		NextProtos: protos,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.reloadIfChanged()
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			if r.cert == nil {
+				return nil, errors.New("certificate is not loaded")
+			}
+			return &tls.Config{
+				Certificates: []tls.Certificate{*r.cert},
+				ClientAuth:   r.conf.ClientAuth,
+				ClientCAs:    r.clientCAs,
+				MinVersion:   r.conf.MinVersion,
+				NextProtos:   protos,
+			}, nil
+		},
+	}
+}
+
+// reloadIfChanged reloads the files when one of them changed, at most once
+// per reload interval. The loaded certificates are kept on failure.
+func (r *CertReloader) reloadIfChanged() {
+	r.mu.RLock()
+	checked := time.Since(r.checkedAt) < r.conf.ReloadInterval
+	r.mu.RUnlock()
+	if checked {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.checkedAt) < r.conf.ReloadInterval {
+		return
+	}
+	r.checkedAt = time.Now()
+	changed := false
+	for _, f := range r.files() {
+		info, err := os.Stat(f)
+		if err != nil {
+			log.Errorf("error stat tls file: %s", err)
+			return
+		}
+		if !info.ModTime().Equal(r.modTimes[f]) {
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+	if err := r.load(); err != nil {
+		log.Errorf("error reload tls files: %s", err)
+		return
+	}
+	log.Infof("tls files reloaded: %s", r.conf.CertFile)
+}
+
+func (r *CertReloader) load() error {
+	modTimes := make(map[string]time.Time)
+	for _, f := range r.files() {
+		info, err := os.Stat(f)
+		if err != nil {
+			return fmt.Errorf("error stat tls file: %w", err)
+		}
+		modTimes[f] = info.ModTime()
+	}
+	cert, err := tls.LoadX509KeyPair(r.conf.CertFile, r.conf.KeyFile)
+	if err != nil {
+		return fmt.Errorf("error load key pair: %w", err)
+	}
+	var clientCAs *x509.CertPool
+	if r.conf.ClientCAFile != "" {
+		b, err := os.ReadFile(r.conf.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("error read client ca file: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(b) {
+			return errors.New("error parse client ca file: no certificate found")
+		}
+	}
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	r.modTimes = modTimes
+	r.checkedAt = time.Now()
+	return nil
+}
+
+func (r *CertReloader) files() []string {
+	files := []string{r.conf.CertFile, r.conf.KeyFile}
+	if r.conf.ClientCAFile != "" {
+		files = append(files, r.conf.ClientCAFile)
+	}
+	return files
+}
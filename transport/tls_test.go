@@ -0,0 +1,142 @@
+package transport
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newTestCert creates a certificate signed by parent, or a self-signed CA when parent is nil.
+func newTestCert(t *testing.T, cn string, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{cert: cert, key: key}
+}
+
+func (c *testCert) write(t *testing.T, certFile, keyFile string) {
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0600))
+	if keyFile == "" {
+		return
+	}
+	b, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: b}), 0600))
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key}
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	conf := &TLSConfig{
+		CertFile:       filepath.Join(dir, "tls.crt"),
+		KeyFile:        filepath.Join(dir, "tls.key"),
+		ClientCAFile:   filepath.Join(dir, "ca.crt"),
+		ClientAuth:     tls.RequireAndVerifyClientCert,
+		ReloadInterval: time.Millisecond,
+	}
+	ca := newTestCert(t, "ca", nil)
+	ca.write(t, conf.ClientCAFile, "")
+	newTestCert(t, "server-1", ca).write(t, conf.CertFile, conf.KeyFile)
+	client := newTestCert(t, "client", ca)
+
+	r := NewCertReloader(conf)
+	require.NoError(t, r.Load())
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	l = tls.NewListener(l, r.TLSConfig("h2"))
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	handshake := func(certs ...tls.Certificate) (string, error) {
+		conn, err := tls.Dial("tcp", l.Addr().String(), &tls.Config{
+			RootCAs:      roots,
+			Certificates: certs,
+			NextProtos:   []string{"h2"},
+		})
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+		// the client learns a rejected certificate on the first read.
+		if _, err := conn.Read(make([]byte, 1)); err != nil && !errors.Is(err, io.EOF) {
+			return "", err
+		}
+		assert.Equal(t, "h2", conn.ConnectionState().NegotiatedProtocol)
+		return conn.ConnectionState().PeerCertificates[0].Subject.CommonName, nil
+	}
+
+	cn, err := handshake(client.tlsCertificate())
+	require.NoError(t, err)
+	assert.Equal(t, "server-1", cn)
+
+	_, err = handshake()
+	assert.Error(t, err)
+
+	// rotate the server certificate.
+	time.Sleep(10 * time.Millisecond)
+	newTestCert(t, "server-2", ca).write(t, conf.CertFile, conf.KeyFile)
+	future := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(conf.CertFile, future, future))
+	time.Sleep(10 * time.Millisecond)
+	cn, err = handshake(client.tlsCertificate())
+	require.NoError(t, err)
+	assert.Equal(t, "server-2", cn)
+}
+
+func TestCertReloaderInvalid(t *testing.T) {
+	r := NewCertReloader(&TLSConfig{CertFile: "not-exist.crt", KeyFile: "not-exist.key"})
+	assert.Error(t, r.Load())
+}
@@ -2,13 +2,16 @@ package transport
 
 import (
 	"context"
+	"net/url"
 )
 
 type Type string
 
 const (
-	TypeHTTP Type = "HTTP"
-	TypeGRPC Type = "GRPC"
+	TypeHTTP  Type = "HTTP"
+	TypeGRPC  Type = "GRPC"
+	TypeAdmin Type = "ADMIN"
+	TypeMux   Type = "MUX"
 )
 
 // Server is transport server.
@@ -17,3 +20,62 @@ type Server interface {
 	Start(context.Context) error
 	Stop(context.Context) error
 }
+
+// ErrorNotifier is implemented by servers which can fail after Start returned.
+type ErrorNotifier interface {
+	// Err returns a channel which receives the error that made the server
+	// stop serving. Nothing is sent when the server is stopped by Stop.
+	Err() <-chan error
+}
+
+// Endpointer is implemented by servers which know the endpoint they serve on.
+type Endpointer interface {
+	// Endpoint returns the URL the server is reachable at after Start,
+	// for example http://127.0.0.1:31234 or grpc://127.0.0.1:31233.
+	Endpoint() (*url.URL, error)
+}
+
+// Readier is a Server reporting the readiness of the application,
+// the App sets it ready after Start completes and unready on Stop.
+type Readier interface {
+	SetReady(ready bool)
+}
+
+// Header is the storage medium of the headers of a Transporter.
+type Header interface {
+	Get(key string) string
+	Set(key string, value string)
+	Add(key string, value string)
+	Keys() []string
+	Values(key string) []string
+}
+
+// Transporter is the transport information of a request handled by a server.
+type Transporter interface {
+	// Kind returns the transport type, TypeHTTP or TypeGRPC.
+	Kind() Type
+	// Endpoint returns the endpoint of the server, for example http://127.0.0.1:31234.
+	Endpoint() string
+	// Operation returns the route template of HTTP, for example
+	// /v1/users/{id}, or the full method name of gRPC, for example
+	// /helloworld.Greeter/SayHello.
+	Operation() string
+	// RequestHeader returns the HTTP request header or gRPC incoming metadata.
+	RequestHeader() Header
+	// ReplyHeader returns the HTTP response header or gRPC outgoing metadata.
+	ReplyHeader() Header
+}
+
+type serverTransportKey struct{}
+
+// NewServerContext returns a new Context that carries the Transporter.
+func NewServerContext(ctx context.Context, tr Transporter) context.Context {
+	return context.WithValue(ctx, serverTransportKey{}, tr)
+}
+
+// FromServerContext returns the Transporter stored in ctx, if any.
+// The HTTP and gRPC servers store it in the context of every request.
+func FromServerContext(ctx context.Context) (Transporter, bool) {
+	tr, ok := ctx.Value(serverTransportKey{}).(Transporter)
+	return tr, ok
+}